@@ -18,6 +18,7 @@ import (
 	"github.com/emerishq/demeris-api-server/lib/apierrors"
 	"github.com/emerishq/demeris-api-server/lib/fflag"
 	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/lib/ratelimit"
 	"github.com/emerishq/demeris-api-server/sdkservice"
 	"github.com/emerishq/demeris-backend-models/cns"
 	"github.com/emerishq/demeris-backend-models/tracelistener"
@@ -28,14 +29,17 @@ const (
 	FixSlashedDelegations = "fixslasheddelegations"
 )
 
-func Register(router *gin.Engine, db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) {
+func Register(router *gin.Engine, db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, sequences *apiutils.Sequences, limiter *ratelimit.Limiter, sequenceLimit ratelimit.Limit) {
 	group := router.Group("/account/:address")
 	group.GET("/balance", GetBalancesByAddress(db))
 	group.GET("/stakingbalances", GetDelegationsByAddress(db))
 	group.GET("/unbondingdelegations", GetUnbondingDelegationsByAddress(db))
 	group.GET("/numbers", GetNumbersByAddress(db, sdkServiceClients))
 	group.GET("/tickets", GetUserTickets(db, s))
+	group.GET("/txs", GetTxsByAddress(db, s, sdkServiceClients))
 	group.GET("/delegatorrewards/:chain", GetDelegatorRewards(db, sdkServiceClients))
+	group.POST("/sequence/:chain", limiter.Middleware("sequence", sequenceLimit), ReserveSequence(db, sdkServiceClients, sequences))
+	group.DELETE("/sequence/:chain/:sequence", limiter.Middleware("sequence", sequenceLimit), ReleaseSequence(sequences))
 }
 
 // GetBalancesByAddress returns account of an address.
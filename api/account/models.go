@@ -1,6 +1,9 @@
 package account
 
 import (
+	"time"
+
+	"github.com/emerishq/demeris-api-server/api/database"
 	"github.com/emerishq/demeris-backend-models/tracelistener"
 )
 
@@ -58,3 +61,16 @@ type DelegatorRewardsResponse struct {
 	Rewards []DelegationDelegatorReward `json:"rewards"`
 	Total   string                      `json:"total"`
 }
+
+type TxsResponse struct {
+	Txs     []database.TxJournalEntry `json:"txs"`
+	NextKey string                    `json:"next_key,omitempty"`
+}
+
+type SequenceResponse struct {
+	ChainName       string    `json:"chain_name"`
+	AccountNumber   uint64    `json:"account_number"`
+	OnChainSequence uint64    `json:"on_chain_sequence"`
+	Sequence        uint64    `json:"sequence"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
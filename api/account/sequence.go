@@ -0,0 +1,176 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+)
+
+// ReserveSequence reserves the next free sequence of an address on a chain.
+// @Summary Reserves a sequence
+// @Description Reserves the next sequence of an address on a chain which is neither used on chain nor taken by
+// @Description a pending transaction or by another reservation, so that transactions sent in a row each get their own.
+// @Description Sequences of transactions which failed are freed first, as their tickets tell.
+// @Description The reservation is released once it expires, or once the transaction signed with it fails.
+// @Description An address holds up to 20 reservations at once on a chain, and reservations are rate limited per
+// @Description client IP and chain.
+// @Tags Account
+// @ID reserve-sequence-account
+// @Produce json
+// @Param address path string true "address to reserve a sequence for"
+// @Param chain path string true "chain to reserve a sequence on"
+// @Success 200 {object} SequenceResponse
+// @Failure 500,400,429 {object} apierrors.UserFacingError
+// @Router /account/{address}/sequence/{chain} [post]
+func ReserveSequence(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients, sequences *apiutils.Sequences) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+
+		address := c.Param("address")
+		chainName := c.Param("chain")
+
+		chain, err := db.Chain(ctx, chainName)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve chain with name %v", chainName),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chain: %w", err),
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		account, err := apiutils.FetchAccountNumbers(ctx, chain, address, sdkServiceClients)
+		if err != nil {
+			e := apierrors.New(
+				"numbers",
+				fmt.Sprintf("cannot retrieve account/sequence numbers for address %v", address),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot query nodes auth for addresses: %w", err),
+				"address",
+				address,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		// a failure here only keeps sequences taken until their ticket expires
+		if err := sequences.ReleaseFailed(ctx, chainName, address); err != nil {
+			logger.Errorw("cannot release sequences of failed txs", "chain", chainName, "address", address, "error", err)
+		}
+
+		sequence, expiry, err := sequences.Reserve(ctx, chainName, address, account.SequenceNumber)
+		if errors.Is(err, apiutils.ErrTooManyReservations) {
+			e := apierrors.New(
+				"sequence",
+				fmt.Sprintf("too many sequences reserved for address %v", address),
+				http.StatusTooManyRequests,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		if err != nil {
+			e := apierrors.New(
+				"sequence",
+				fmt.Sprintf("cannot reserve sequence for address %v", address),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot reserve sequence: %w", err),
+				"address",
+				address,
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, SequenceResponse{
+			ChainName:       chainName,
+			AccountNumber:   account.AccountNumber,
+			OnChainSequence: account.SequenceNumber,
+			Sequence:        sequence,
+			ExpiresAt:       expiry,
+		})
+	}
+}
+
+// ReleaseSequence releases a sequence reserved by an address on a chain.
+// @Summary Releases a reserved sequence
+// @Description Releases a sequence reserved by an address on a chain, for instance because the transaction it was
+// @Description reserved for won't be sent. A sequence held by a relayed transaction isn't released.
+// @Tags Account
+// @ID release-sequence-account
+// @Param address path string true "address to release a sequence of"
+// @Param chain path string true "chain to release a sequence on"
+// @Param sequence path int true "sequence to release"
+// @Success 204
+// @Failure 500,400,409,429 {object} apierrors.UserFacingError
+// @Router /account/{address}/sequence/{chain}/{sequence} [delete]
+func ReleaseSequence(sequences *apiutils.Sequences) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Param("address")
+		chainName := c.Param("chain")
+
+		sequence, err := strconv.ParseUint(c.Param("sequence"), 10, 64)
+		if err != nil {
+			e := apierrors.New("sequence", fmt.Sprintf("invalid sequence %v", c.Param("sequence")), http.StatusBadRequest)
+			_ = c.Error(e)
+
+			return
+		}
+
+		err = sequences.ReleaseReserved(c.Request.Context(), chainName, address, sequence)
+		if errors.Is(err, apiutils.ErrSequenceHeld) {
+			e := apierrors.New(
+				"sequence",
+				fmt.Sprintf("sequence %d is held by a relayed transaction", sequence),
+				http.StatusConflict,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		if err != nil {
+			e := apierrors.New(
+				"sequence",
+				fmt.Sprintf("cannot release sequence %d for address %v", sequence, address),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot release sequence: %w", err),
+				"address",
+				address,
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
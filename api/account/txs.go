@@ -0,0 +1,216 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/emerishq/emeris-utils/store"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+)
+
+const (
+	defaultTxsLimit = 50
+	maxTxsLimit     = 200
+
+	// maxSettledTxQueries bounds the sdk-service calls made to settle the
+	// pending txs of a single page.
+	maxSettledTxQueries = 10
+)
+
+// GetTxsByAddress returns the transactions relayed for an address.
+// @Summary Gets the transactions relayed for an address.
+// @Tags Account
+// @ID get-txs-address
+// @Description Gets the transactions relayed through the api-server for an address, as their owner or signer,
+// @Description most recent first. Results are paginated, the next page is requested by setting key to next_key.
+// @Description Pending transactions are settled from their ticket, or from their chain once their ticket expired.
+// @Param address path string true "address to query transactions for"
+// @Param chain query string false "only return transactions of this chain"
+// @Param status query string false "only return transactions with this status, e.g. pending, complete or failed"
+// @Param msg_type query string false "only return transactions with a message of this type"
+// @Param from query string false "only return transactions submitted at or after this RFC3339 time"
+// @Param to query string false "only return transactions submitted before this RFC3339 time"
+// @Param limit query int false "maximum number of transactions returned, 50 by default and at most 200"
+// @Param key query string false "pagination key, as returned in next_key"
+// @Produce json
+// @Success 200 {object} TxsResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /account/{address}/txs [get]
+func GetTxsByAddress(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+
+		address := c.Param("address")
+
+		filter, err := txJournalFilter(c)
+		if err != nil {
+			e := apierrors.New(
+				"account",
+				err.Error(),
+				http.StatusBadRequest,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		txs, err := db.TxJournal(ctx, address, filter)
+		if err != nil {
+			e := apierrors.New(
+				"account",
+				fmt.Sprintf("cannot retrieve transactions for address %v", address),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot query database tx journal for address: %w", err),
+				"address",
+				address,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		settler := txSettler{
+			s: s,
+			queryTx: func(ctx context.Context, chainName, txHash string) ([]byte, error) {
+				chain, err := db.Chain(ctx, chainName)
+				if err != nil {
+					return nil, err
+				}
+
+				client, err := sdkServiceClients.GetSDKServiceClient(chain.MajorSDKVersion())
+				if err != nil {
+					return nil, err
+				}
+
+				return client.QueryTx(ctx, &sdkutilities.QueryTxPayload{
+					ChainName: chainName,
+					Hash:      txHash,
+				})
+			},
+			queriesLeft: maxSettledTxQueries,
+		}
+
+		for i, tx := range txs {
+			if tx.Status != apiutils.TxJournalPending {
+				continue
+			}
+
+			res, ok := settler.settle(ctx, tx)
+			if !ok {
+				continue
+			}
+
+			if err := db.SetTxJournalResult(ctx, tx.ChainName, tx.TxHash, res); err != nil {
+				logger.Errorw("cannot journal tx result", "chain", tx.ChainName, "hash", tx.TxHash, "error", err)
+			}
+
+			txs[i].Status = res.Status
+			txs[i].Height = res.Height
+			txs[i].Code = res.Code
+			txs[i].Error = res.Error
+		}
+
+		res := TxsResponse{
+			Txs: txs,
+		}
+
+		if res.Txs == nil {
+			res.Txs = []database.TxJournalEntry{}
+		}
+
+		if len(txs) == filter.Limit {
+			res.NextKey = strconv.FormatInt(txs[len(txs)-1].ID, 10)
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// txSettler finds the results of pending journaled txs.
+type txSettler struct {
+	s *store.Store
+	// queryTx returns the tx as returned by sdk-service QueryTx.
+	queryTx     func(ctx context.Context, chainName, txHash string) ([]byte, error)
+	queriesLeft int
+}
+
+// settle returns the result of the pending tx, caught up from its ticket
+// while it lives, or else from the chain. It returns false if the result
+// isn't known yet.
+func (t *txSettler) settle(ctx context.Context, tx database.TxJournalEntry) (database.TxJournalResult, bool) {
+	ticket, err := t.s.Get(store.GetKey(tx.ChainName, tx.TxHash))
+	if err == nil {
+		return apiutils.TicketJournalResult(ticket)
+	}
+
+	// the ticket expired, the tx is looked up on its chain instead
+	if t.queriesLeft <= 0 {
+		return database.TxJournalResult{}, false
+	}
+
+	t.queriesLeft--
+
+	data, err := t.queryTx(ctx, tx.ChainName, tx.TxHash)
+	if err != nil {
+		return database.TxJournalResult{}, false
+	}
+
+	return apiutils.QueryTxJournalResult(data)
+}
+
+// txJournalFilter parses the filter and pagination query parameters of
+// GetTxsByAddress.
+func txJournalFilter(c *gin.Context) (database.TxJournalFilter, error) {
+	filter := database.TxJournalFilter{
+		ChainName: c.Query("chain"),
+		Status:    c.Query("status"),
+		MsgType:   c.Query("msg_type"),
+		Limit:     defaultTxsLimit,
+	}
+
+	var err error
+
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return database.TxJournalFilter{}, fmt.Errorf("invalid from time %q, must be RFC3339", from)
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return database.TxJournalFilter{}, fmt.Errorf("invalid to time %q, must be RFC3339", to)
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return database.TxJournalFilter{}, fmt.Errorf("invalid limit %q, must be a positive integer", limit)
+		}
+
+		if filter.Limit > maxTxsLimit {
+			filter.Limit = maxTxsLimit
+		}
+	}
+
+	if key := c.Query("key"); key != "" {
+		if filter.Before, err = strconv.ParseInt(key, 10, 64); err != nil || filter.Before <= 0 {
+			return database.TxJournalFilter{}, fmt.Errorf("invalid pagination key %q", key)
+		}
+	}
+
+	return filter, nil
+}
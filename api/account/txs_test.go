@@ -0,0 +1,139 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+)
+
+func Test_txJournalFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    database.TxJournalFilter
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  database.TxJournalFilter{Limit: defaultTxsLimit},
+		},
+		{
+			name:  "all filters",
+			query: "chain=cosmos-hub&status=complete&msg_type=/cosmos.bank.v1beta1.MsgSend&from=2022-05-01T00:00:00Z&to=2022-06-01T00:00:00Z&limit=10&key=42",
+			want: database.TxJournalFilter{
+				ChainName: "cosmos-hub",
+				Status:    "complete",
+				MsgType:   "/cosmos.bank.v1beta1.MsgSend",
+				From:      time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC),
+				To:        time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+				Before:    42,
+				Limit:     10,
+			},
+		},
+		{
+			name:  "bounded limit",
+			query: "limit=1000",
+			want:  database.TxJournalFilter{Limit: maxTxsLimit},
+		},
+		{name: "invalid limit", query: "limit=0", wantErr: true},
+		{name: "invalid key", query: "key=abc", wantErr: true},
+		{name: "invalid from", query: "from=yesterday", wantErr: true},
+		{name: "invalid to", query: "to=2022-06-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/account/address/txs?"+tt.query, nil)
+
+			filter, err := txJournalFilter(c)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, filter)
+		})
+	}
+}
+
+func Test_txSettler(t *testing.T) {
+	ctx := context.Background()
+	m := miniredis.RunT(t)
+	s, err := store.NewClient(m.Addr())
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateTicket("cosmos-hub", "LIVE", "owner"))
+	require.NoError(t, s.CreateTicket("cosmos-hub", "DONE", "owner"))
+	require.NoError(t, s.SetComplete(store.GetKey("cosmos-hub", "DONE"), 12))
+
+	queried := map[string]int{}
+	settler := txSettler{
+		s: s,
+		queryTx: func(_ context.Context, _, txHash string) ([]byte, error) {
+			queried[txHash]++
+
+			switch txHash {
+			case "COMMITTED":
+				return []byte(`{"tx_response":{"height":"42","code":0}}`), nil
+			case "REVERTED":
+				return []byte(`{"tx_response":{"height":"43","code":5,"raw_log":"insufficient funds"}}`), nil
+			case "MEMPOOL":
+				return []byte(`{"tx_response":{"height":"0"}}`), nil
+			}
+
+			return nil, errors.New("tx not found")
+		},
+		queriesLeft: 4,
+	}
+
+	settle := func(txHash string) (database.TxJournalResult, bool) {
+		return settler.settle(ctx, database.TxJournalEntry{ChainName: "cosmos-hub", TxHash: txHash})
+	}
+
+	// live tickets are used while they exist
+	_, ok := settle("LIVE")
+	require.False(t, ok)
+
+	res, ok := settle("DONE")
+	require.True(t, ok)
+	require.Equal(t, "complete", res.Status)
+	require.Equal(t, int64(12), *res.Height)
+
+	require.Empty(t, queried)
+
+	// txs whose ticket expired are looked up on their chain
+	res, ok = settle("COMMITTED")
+	require.True(t, ok)
+	require.Equal(t, "complete", res.Status)
+	require.Equal(t, int64(42), *res.Height)
+	require.Equal(t, int64(0), *res.Code)
+
+	res, ok = settle("REVERTED")
+	require.True(t, ok)
+	require.Equal(t, "failed", res.Status)
+	require.Equal(t, int64(5), *res.Code)
+	require.Equal(t, "insufficient funds", *res.Error)
+
+	_, ok = settle("MEMPOOL")
+	require.False(t, ok)
+
+	_, ok = settle("UNKNOWN")
+	require.False(t, ok)
+
+	// queries are bounded
+	_, ok = settle("COMMITTED")
+	require.False(t, ok)
+	require.Equal(t, 1, queried["COMMITTED"])
+}
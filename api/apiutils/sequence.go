@@ -0,0 +1,245 @@
+package apiutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// sequencePrefix prefixes the redis keys of sequence reservations.
+	sequencePrefix = "api-server/sequence"
+
+	// ticketFailed is the status of tickets whose tx failed.
+	ticketFailed = "failed"
+
+	// maxReservedSequences bounds the sequences an account reserves at once,
+	// held ones aside.
+	maxReservedSequences = 20
+)
+
+var (
+	// ErrTooManyReservations is returned when an account reserves more than
+	// maxReservedSequences sequences.
+	ErrTooManyReservations = errors.New("too many reserved sequences")
+	// ErrSequenceHeld is returned when releasing a sequence held by a tx.
+	ErrSequenceHeld = errors.New("sequence held by a relayed tx")
+)
+
+// reserveSequence atomically prunes the reservations of an account, then
+// reserves its lowest free sequence.
+// Reservations are the members of a sorted set scored by their expiry, in
+// unix milliseconds.
+// It returns -1 if the account has too many reservations already.
+//
+// KEYS[1]: reservations of the account
+// KEYS[2]: txs holding sequences of the account
+// ARGV[1]: now
+// ARGV[2]: on-chain sequence of the account
+// ARGV[3]: expiry of the reservation
+// ARGV[4]: maximum number of reservations, held sequences aside
+var reserveSequence = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+
+local seq = tonumber(ARGV[2])
+local reserved = 0
+for _, m in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	if tonumber(m) < seq then
+		redis.call('ZREM', KEYS[1], m)
+	elseif redis.call('HEXISTS', KEYS[2], m) == 0 then
+		reserved = reserved + 1
+	end
+end
+
+if reserved >= tonumber(ARGV[4]) then
+	return -1
+end
+
+while redis.call('ZSCORE', KEYS[1], string.format('%d', seq)) do
+	seq = seq + 1
+end
+
+redis.call('ZADD', KEYS[1], ARGV[3], string.format('%d', seq))
+
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+redis.call('PEXPIREAT', KEYS[1], last[2])
+
+return seq
+`)
+
+// Sequences tracks the sequences of accounts taken by txs which aren't on
+// chain yet, so that the txs an account sends in a row are each handed a
+// distinct sequence.
+//
+// A sequence is either reserved by a client about to sign a tx, until the
+// reservation expires, or held by a relayed tx, until its ticket expires.
+// Sequences below the on-chain sequence of the account are used already and
+// dropped.
+type Sequences struct {
+	s   *store.Store
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewSequences returns a Sequences keeping its state in s, whose
+// reservations expire after ttl.
+func NewSequences(s *store.Store, ttl time.Duration) *Sequences {
+	return &Sequences{
+		s:   s,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// sequenceKey returns the key of the sequences taken by the hex-encoded
+// address on chain.
+func sequenceKey(chain, address string) string {
+	return fmt.Sprintf("%s/%s/%s", sequencePrefix, chain, address)
+}
+
+// heldSequenceKey returns the key of the txs holding sequences of the
+// hex-encoded address on chain, indexed by sequence.
+func heldSequenceKey(chain, address string) string {
+	return fmt.Sprintf("%s/held/%s/%s", sequencePrefix, chain, address)
+}
+
+// Reserve returns the lowest sequence of the hex-encoded address on chain
+// which is neither below onChain, the next sequence of the account on chain,
+// nor taken, and reserves it until the returned expiry.
+// ErrTooManyReservations is returned if the account reserved too many
+// sequences already.
+func (q *Sequences) Reserve(ctx context.Context, chain, address string, onChain uint64) (uint64, time.Time, error) {
+	now := q.now()
+	expiry := now.Add(q.ttl)
+
+	seq, err := reserveSequence.Run(
+		ctx,
+		q.s.Client,
+		[]string{sequenceKey(chain, address), heldSequenceKey(chain, address)},
+		now.UnixMilli(),
+		onChain,
+		expiry.UnixMilli(),
+		maxReservedSequences,
+	).Int64()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("cannot reserve sequence, %w", err)
+	}
+
+	if seq < 0 {
+		return 0, time.Time{}, ErrTooManyReservations
+	}
+
+	return uint64(seq), expiry, nil
+}
+
+// Hold marks sequence of the hex-encoded address on chain as taken by the tx
+// txHash, for as long as its ticket lives.
+func (q *Sequences) Hold(ctx context.Context, chain, address string, sequence uint64, txHash string) error {
+	key, heldKey := sequenceKey(chain, address), heldSequenceKey(chain, address)
+	member := strconv.FormatUint(sequence, 10)
+	expiry := q.now().Add(q.s.Config.ExpiryTime)
+
+	_, err := q.s.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(ctx, key, &redis.Z{Score: float64(expiry.UnixMilli()), Member: member})
+		p.PExpireAt(ctx, key, expiry)
+		p.HSet(ctx, heldKey, member, txHash)
+		p.PExpireAt(ctx, heldKey, expiry)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot hold sequence, %w", err)
+	}
+
+	return nil
+}
+
+// Release frees sequence of the hex-encoded address on chain.
+func (q *Sequences) Release(ctx context.Context, chain, address string, sequence uint64) error {
+	member := strconv.FormatUint(sequence, 10)
+
+	_, err := q.s.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, sequenceKey(chain, address), member)
+		p.HDel(ctx, heldSequenceKey(chain, address), member)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot release sequence, %w", err)
+	}
+
+	return nil
+}
+
+// releaseReservedSequence atomically frees a sequence unless a tx holds it.
+// It returns 0 if the sequence is held.
+//
+// KEYS[1]: reservations of the account
+// KEYS[2]: txs holding sequences of the account
+// ARGV[1]: sequence
+var releaseReservedSequence = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[2], ARGV[1]) == 1 then
+	return 0
+end
+
+redis.call('ZREM', KEYS[1], ARGV[1])
+
+return 1
+`)
+
+// ReleaseReserved frees sequence of the hex-encoded address on chain, if it's
+// only reserved. ErrSequenceHeld is returned if a relayed tx holds it.
+func (q *Sequences) ReleaseReserved(ctx context.Context, chain, address string, sequence uint64) error {
+	released, err := releaseReservedSequence.Run(
+		ctx,
+		q.s.Client,
+		[]string{sequenceKey(chain, address), heldSequenceKey(chain, address)},
+		strconv.FormatUint(sequence, 10),
+	).Int64()
+	if err != nil {
+		return fmt.Errorf("cannot release sequence, %w", err)
+	}
+
+	if released == 0 {
+		return ErrSequenceHeld
+	}
+
+	return nil
+}
+
+// ReleaseFailed frees the sequences of the hex-encoded address on chain held
+// by txs whose ticket failed, or expired.
+// A failed tx may not have used its sequence, which is then free again.
+func (q *Sequences) ReleaseFailed(ctx context.Context, chain, address string) error {
+	held, err := q.s.Client.HGetAll(ctx, heldSequenceKey(chain, address)).Result()
+	if err != nil {
+		return fmt.Errorf("cannot get held sequences, %w", err)
+	}
+
+	for member, txHash := range held {
+		ticket, err := q.s.Get(store.GetKey(chain, txHash))
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("cannot get ticket, %w", err)
+		}
+
+		if err == nil && ticket.Status != ticketFailed {
+			continue
+		}
+
+		sequence, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if err := q.Release(ctx, chain, address, sequence); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
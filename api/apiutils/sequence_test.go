@@ -0,0 +1,150 @@
+package apiutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSequences returns a Sequences on a fresh miniredis, along with a
+// function moving both their clocks forward.
+func newTestSequences(t *testing.T, ttl time.Duration) (*store.Store, *Sequences, func(time.Duration)) {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+
+	s, err := store.NewClient(m.Addr())
+	require.NoError(t, err)
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	m.SetTime(now)
+
+	q := NewSequences(s, ttl)
+	q.now = func() time.Time { return now }
+
+	advance := func(d time.Duration) {
+		now = now.Add(d)
+		m.SetTime(now)
+		m.FastForward(d)
+	}
+
+	return s, q, advance
+}
+
+func reserve(t *testing.T, q *Sequences, onChain uint64) uint64 {
+	t.Helper()
+
+	seq, expiry, err := q.Reserve(context.Background(), "cosmos-hub", "abcd", onChain)
+	require.NoError(t, err)
+	require.Equal(t, q.now().Add(q.ttl), expiry)
+
+	return seq
+}
+
+func TestSequences_Reserve(t *testing.T) {
+	ctx := context.Background()
+	_, q, _ := newTestSequences(t, time.Minute)
+
+	require.Equal(t, uint64(5), reserve(t, q, 5))
+	require.Equal(t, uint64(6), reserve(t, q, 5))
+	require.Equal(t, uint64(7), reserve(t, q, 5))
+
+	// released sequences are handed out again first
+	require.NoError(t, q.Release(ctx, "cosmos-hub", "abcd", 6))
+	require.Equal(t, uint64(6), reserve(t, q, 5))
+	require.Equal(t, uint64(8), reserve(t, q, 5))
+
+	// sequences used on chain are dropped
+	require.Equal(t, uint64(9), reserve(t, q, 7))
+	require.Equal(t, uint64(12), reserve(t, q, 12))
+	require.Equal(t, uint64(13), reserve(t, q, 12))
+
+	// other accounts and chains are tracked apart
+	seq, _, err := q.Reserve(ctx, "cosmos-hub", "ef01", 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), seq)
+
+	seq, _, err = q.Reserve(ctx, "osmosis", "abcd", 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), seq)
+}
+
+func TestSequences_Reserve_expiry(t *testing.T) {
+	_, q, advance := newTestSequences(t, time.Minute)
+
+	require.Equal(t, uint64(3), reserve(t, q, 3))
+	require.Equal(t, uint64(4), reserve(t, q, 3))
+
+	advance(time.Minute + time.Millisecond)
+
+	require.Equal(t, uint64(3), reserve(t, q, 3))
+}
+
+func TestSequences_ReleaseFailed(t *testing.T) {
+	ctx := context.Background()
+	s, q, advance := newTestSequences(t, time.Minute)
+
+	require.NoError(t, s.CreateTicket("cosmos-hub", "HASH1", "owner"))
+	require.NoError(t, s.CreateTicket("cosmos-hub", "HASH2", "owner"))
+	require.NoError(t, q.Hold(ctx, "cosmos-hub", "abcd", 3, "HASH1"))
+	require.NoError(t, q.Hold(ctx, "cosmos-hub", "abcd", 4, "HASH2"))
+
+	// held sequences outlive reservations
+	advance(time.Minute + time.Millisecond)
+	require.Equal(t, uint64(5), reserve(t, q, 3))
+
+	require.NoError(t, s.SetFailedWithErr(store.GetKey("cosmos-hub", "HASH1"), "out of gas", 0))
+	require.NoError(t, q.ReleaseFailed(ctx, "cosmos-hub", "abcd"))
+
+	// only the sequence of the failed tx is free again
+	require.Equal(t, uint64(3), reserve(t, q, 3))
+	require.Equal(t, uint64(6), reserve(t, q, 3))
+
+	// the sequence of a failed tx is released once
+	require.NoError(t, q.ReleaseFailed(ctx, "cosmos-hub", "abcd"))
+	require.Equal(t, uint64(7), reserve(t, q, 3))
+
+	// so is the sequence of a tx whose ticket expired
+	require.NoError(t, s.Delete(store.GetKey("cosmos-hub", "HASH2")))
+	require.NoError(t, q.ReleaseFailed(ctx, "cosmos-hub", "abcd"))
+	require.Equal(t, uint64(4), reserve(t, q, 3))
+}
+
+func TestSequences_ReserveLimit(t *testing.T) {
+	ctx := context.Background()
+	_, q, advance := newTestSequences(t, time.Minute)
+
+	for i := 0; i < maxReservedSequences; i++ {
+		reserve(t, q, 0)
+	}
+
+	_, _, err := q.Reserve(ctx, "cosmos-hub", "abcd", 0)
+	require.ErrorIs(t, err, ErrTooManyReservations)
+
+	// held sequences don't count
+	require.NoError(t, q.Hold(ctx, "cosmos-hub", "abcd", 0, "HASH"))
+	require.Equal(t, uint64(maxReservedSequences), reserve(t, q, 0))
+
+	// neither do expired reservations
+	advance(time.Minute + time.Millisecond)
+	require.Equal(t, uint64(1), reserve(t, q, 0))
+}
+
+func TestSequences_ReleaseReserved(t *testing.T) {
+	ctx := context.Background()
+	_, q, _ := newTestSequences(t, time.Minute)
+
+	require.Equal(t, uint64(3), reserve(t, q, 3))
+	require.Equal(t, uint64(4), reserve(t, q, 3))
+	require.NoError(t, q.Hold(ctx, "cosmos-hub", "abcd", 3, "HASH"))
+
+	// sequences held by relayed txs are kept
+	require.ErrorIs(t, q.ReleaseReserved(ctx, "cosmos-hub", "abcd", 3), ErrSequenceHeld)
+
+	require.NoError(t, q.ReleaseReserved(ctx, "cosmos-hub", "abcd", 4))
+	require.Equal(t, uint64(4), reserve(t, q, 3))
+}
@@ -0,0 +1,15 @@
+package apiutils
+
+// StringValue returns the string s points to, or an empty string if s is nil.
+func StringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// StringPtr returns a pointer to s.
+func StringPtr(s string) *string {
+	return &s
+}
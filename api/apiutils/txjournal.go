@@ -0,0 +1,69 @@
+package apiutils
+
+import (
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/tidwall/gjson"
+)
+
+// Journal statuses, matching the ticket ones.
+const (
+	// TxJournalPending is the status of journaled transactions whose result
+	// isn't known yet.
+	TxJournalPending  = "pending"
+	TxJournalComplete = "complete"
+	TxJournalFailed   = "failed"
+)
+
+// queryTxResponsePath may need updates if the sdk-service tx format changes.
+const queryTxResponsePath = "tx_response"
+
+// TicketJournalResult returns the journal result of the transaction tracked
+// by ticket, or false if it's still pending.
+func TicketJournalResult(ticket store.Ticket) (database.TxJournalResult, bool) {
+	if ticket.Status == "" || ticket.Status == TxJournalPending {
+		return database.TxJournalResult{}, false
+	}
+
+	res := database.TxJournalResult{
+		Status: ticket.Status,
+	}
+
+	if ticket.Height != 0 {
+		height := ticket.Height
+		res.Height = &height
+	}
+
+	if ticket.Error != "" {
+		e := ticket.Error
+		res.Error = &e
+	}
+
+	return res, true
+}
+
+// QueryTxJournalResult returns the journal result of a transaction, as
+// returned by sdk-service QueryTx, or false if it isn't in a block.
+func QueryTxJournalResult(data []byte) (database.TxJournalResult, bool) {
+	txResponse := gjson.GetBytes(data, queryTxResponsePath)
+
+	height := txResponse.Get("height").Int()
+	if height <= 0 {
+		return database.TxJournalResult{}, false
+	}
+
+	code := txResponse.Get("code").Int()
+	res := database.TxJournalResult{
+		Status: TxJournalComplete,
+		Height: &height,
+		Code:   &code,
+	}
+
+	if code != 0 {
+		e := txResponse.Get("raw_log").String()
+		res.Status = TxJournalFailed
+		res.Error = &e
+	}
+
+	return res, true
+}
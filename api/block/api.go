@@ -10,13 +10,20 @@ import (
 
 	"github.com/emerishq/emeris-utils/store"
 
+	"github.com/emerishq/demeris-api-server/api/chains"
 	"github.com/emerishq/demeris-api-server/api/database"
 	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/sdkservice"
 	"github.com/gin-gonic/gin"
 )
 
-func Register(router *gin.Engine, db *database.Database, s *store.Store) {
+func Register(router *gin.Engine, db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) {
 	router.GET("/block_results", GetBlock(db, s))
+
+	router.Group("/chain/:chain/block").
+		Use(chains.GetChainMiddleware("chain", db)).
+		GET("/latest", GetChainLatestBlock(db, s, sdkServiceClients)).
+		GET("/:height", GetChainBlock(s, sdkServiceClients))
 }
 
 // GetBlock returns a Tendermint block data at a given height.
@@ -0,0 +1,257 @@
+package block
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/emerishq/emeris-utils/store"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/chains"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/lib/stringcache"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+)
+
+const (
+	// blocks never change once committed, they're only evicted to bound
+	// the size of the cache
+	blockCacheDuration = time.Hour
+	blockCachePrefix   = "api-server/block"
+)
+
+// Paths of header fields in blocks returned by sdk-service, which may need
+// updates if the sdk-service block format changes.
+const (
+	blockHashPath     = "block_id.hash"
+	blockHeightPath   = "block.header.height"
+	blockTimePath     = "block.header.time"
+	blockProposerPath = "block.header.proposer_address"
+	blockTxsPath      = "block.data.txs"
+)
+
+// GetChainBlock returns the block of a chain at a given height.
+// @Summary Returns the block of a chain at a given height.
+// @Tags Block
+// @ID get-chain-block
+// @Description Returns the block of a chain at a given height, along with its header fields.
+// @Description Blocks are read from the cache first, or else queried from the chain and cached.
+// @Produce json
+// @Param chain path string true "chain name"
+// @Param height path int true "height of the block"
+// @Success 200 {object} ChainBlockResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /chain/{chain}/block/{height} [get]
+func GetChainBlock(s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chain := ginutils.GetValue[cns.Chain](c, chains.ChainContextKey)
+
+		height, err := strconv.ParseInt(c.Param("height"), 10, 64)
+		if err != nil || height <= 0 {
+			e := apierrors.New(
+				"block",
+				fmt.Sprintf("malformed height"),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot query block, malformed height: %w", err),
+				"height_string",
+				c.Param("height"),
+			)
+			_ = c.Error(e)
+			return
+		}
+
+		res, e := chainBlock(c, s, sdkServiceClients, chain, height)
+		if e != nil {
+			_ = c.Error(e)
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// GetChainLatestBlock returns the latest block of a chain.
+// @Summary Returns the latest block of a chain.
+// @Tags Block
+// @ID get-chain-latest-block
+// @Description Returns the latest block of a chain known to the api-server, along with its header fields.
+// @Produce json
+// @Param chain path string true "chain name"
+// @Success 200 {object} ChainBlockResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /chain/{chain}/block/latest [get]
+func GetChainLatestBlock(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chain := ginutils.GetValue[cns.Chain](c, chains.ChainContextKey)
+
+		lastBlock, err := db.ChainLastBlock(c.Request.Context(), chain.ChainName)
+		if err != nil || lastBlock.Height == 0 {
+			if err == nil {
+				err = fmt.Errorf("no block recorded")
+			}
+
+			e := apierrors.New(
+				"block",
+				fmt.Sprintf("cannot get latest block of chain %v", chain.ChainName),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot query last block: %w", err),
+				"name",
+				chain.ChainName,
+			)
+			_ = c.Error(e)
+			return
+		}
+
+		res, e := chainBlock(c, s, sdkServiceClients, chain, int64(lastBlock.Height))
+		if e != nil {
+			_ = c.Error(e)
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// chainBlock returns the block of chain at height, read from the cache or
+// else queried from sdk-service and cached.
+func chainBlock(c *gin.Context, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, chain cns.Chain, height int64) (ChainBlockResponse, *apierrors.Error) {
+	logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+
+	client, err := sdkServiceClients.GetSDKServiceClient(chain.MajorSDKVersion())
+	if err != nil {
+		return ChainBlockResponse{}, apierrors.Wrap(
+			err,
+			"block",
+			fmt.Sprintf("cannot retrieve sdk-service for chain %v", chain.ChainName),
+			http.StatusBadRequest,
+		)
+	}
+
+	blockCache := stringcache.NewStringCache(
+		logger,
+		stringcache.NewStoreBackend(s),
+		blockCacheDuration,
+		blockCachePrefix,
+		stringcache.HandlerFunc(
+			func(ctx context.Context, _ string) (string, error) {
+				res, err := client.Block(ctx, &sdkutilities.BlockPayload{
+					ChainName: chain.ChainName,
+					Height:    height,
+				})
+				if err != nil {
+					return "", err
+				}
+
+				return string(res.Block), nil
+			},
+		),
+	)
+
+	data, err := blockCache.Get(c.Request.Context(), fmt.Sprintf("%s/%d", chain.ChainName, height), false)
+	if err != nil {
+		return ChainBlockResponse{}, apierrors.New(
+			"block",
+			fmt.Sprintf("cannot get block at height %v", height),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot query block: %w", err),
+			"name",
+			chain.ChainName,
+			"height",
+			height,
+		)
+	}
+
+	res, err := blockResponse(chain.ChainName, []byte(data))
+	if err != nil {
+		return ChainBlockResponse{}, apierrors.New(
+			"block",
+			fmt.Sprintf("cannot read block at height %v", height),
+			http.StatusInternalServerError,
+		).WithLogContext(
+			fmt.Errorf("cannot parse block: %w", err),
+			"name",
+			chain.ChainName,
+			"height",
+			height,
+		)
+	}
+
+	return res, nil
+}
+
+// blockResponse returns the block data of chainName along with its header
+// fields.
+func blockResponse(chainName string, data []byte) (ChainBlockResponse, error) {
+	if !gjson.ValidBytes(data) {
+		return ChainBlockResponse{}, fmt.Errorf("invalid block JSON")
+	}
+
+	block := gjson.ParseBytes(data)
+
+	height, err := strconv.ParseInt(block.Get(blockHeightPath).String(), 10, 64)
+	if err != nil {
+		return ChainBlockResponse{}, fmt.Errorf("invalid height, %w", err)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, block.Get(blockTimePath).String())
+	if err != nil {
+		return ChainBlockResponse{}, fmt.Errorf("invalid time, %w", err)
+	}
+
+	hash, err := hexBytes(block.Get(blockHashPath).String())
+	if err != nil {
+		return ChainBlockResponse{}, fmt.Errorf("invalid hash, %w", err)
+	}
+
+	proposer, err := hexBytes(block.Get(blockProposerPath).String())
+	if err != nil {
+		return ChainBlockResponse{}, fmt.Errorf("invalid proposer address, %w", err)
+	}
+
+	return ChainBlockResponse{
+		ChainName:       chainName,
+		Height:          height,
+		Hash:            hash,
+		Time:            t,
+		ProposerAddress: proposer,
+		TxCount:         len(block.Get(blockTxsPath).Array()),
+		Block:           json.RawMessage(data),
+	}, nil
+}
+
+// hexBytes returns the upper case hex encoding of bytes encoded in v, as
+// Tendermint displays hashes and addresses.
+// Bytes are encoded in hex by Tendermint RPC, but in base64 by gRPC gateways.
+func hexBytes(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+
+	if _, err := hex.DecodeString(v); err == nil {
+		return strings.ToUpper(v), nil
+	}
+
+	bz, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(hex.EncodeToString(bz)), nil
+}
@@ -0,0 +1,144 @@
+package block
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/emerishq/emeris-utils/store"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/chains"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+)
+
+// grpcBlock is a block as returned by sdk-service, with bytes in base64.
+const grpcBlock = `{
+	"block_id": {"hash": "6q3YB7/8ZOYe1gMAR5+I9i4gRHPQRZJTx1XSbcTkPOI="},
+	"block": {
+		"header": {
+			"chain_id": "cosmoshub-4",
+			"height": "10234",
+			"time": "2022-04-26T10:12:43.123456789Z",
+			"proposer_address": "g3ag2ZRrqnB/7k+KnRc3JQXQwCw="
+		},
+		"data": {"txs": ["dHgx", "dHgy"]}
+	}
+}`
+
+// fakeBlocks is an sdk-service only serving blocks.
+type fakeBlocks struct {
+	sdkutilities.Service
+
+	calls  int
+	blocks map[int64]string
+}
+
+func (f *fakeBlocks) Block(_ context.Context, p *sdkutilities.BlockPayload) (*sdkutilities.BlockData, error) {
+	f.calls++
+
+	b, ok := f.blocks[p.Height]
+	if !ok {
+		return nil, fmt.Errorf("height %d is not available", p.Height)
+	}
+
+	return &sdkutilities.BlockData{Height: p.Height, Block: []byte(b)}, nil
+}
+
+func Test_blockResponse(t *testing.T) {
+	res, err := blockResponse("cosmos-hub", []byte(grpcBlock))
+	require.NoError(t, err)
+
+	require.Equal(t, "cosmos-hub", res.ChainName)
+	require.Equal(t, int64(10234), res.Height)
+	require.Equal(t, "EAADD807BFFC64E61ED60300479F88F62E204473D0459253C755D26DC4E43CE2", res.Hash)
+	require.Equal(t, "8376A0D9946BAA707FEE4F8A9D17372505D0C02C", res.ProposerAddress)
+	require.Equal(t, time.Date(2022, 4, 26, 10, 12, 43, 123456789, time.UTC), res.Time)
+	require.Equal(t, 2, res.TxCount)
+	require.JSONEq(t, grpcBlock, string(res.Block))
+
+	// Tendermint RPC encodes bytes in hex
+	res, err = blockResponse("cosmos-hub", []byte(`{
+		"block_id": {"hash": "eaadd807bffc64e61ed60300479f88f62e204473d0459253c755d26dc4e43ce2"},
+		"block": {
+			"header": {"height": "1", "time": "2022-04-26T10:12:43Z", "proposer_address": "8376A0D9946BAA707FEE4F8A9D17372505D0C02C"},
+			"data": {"txs": null}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "EAADD807BFFC64E61ED60300479F88F62E204473D0459253C755D26DC4E43CE2", res.Hash)
+	require.Equal(t, "8376A0D9946BAA707FEE4F8A9D17372505D0C02C", res.ProposerAddress)
+	require.Equal(t, 0, res.TxCount)
+
+	_, err = blockResponse("cosmos-hub", []byte(`not json`))
+	require.Error(t, err)
+
+	_, err = blockResponse("cosmos-hub", []byte(`{"block": {"header": {"height": "1", "time": "yesterday"}}}`))
+	require.Error(t, err)
+}
+
+func TestGetChainBlock(t *testing.T) {
+	m := miniredis.RunT(t)
+	s, err := store.NewClient(m.Addr())
+	require.NoError(t, err)
+
+	service := &fakeBlocks{blocks: map[int64]string{10234: grpcBlock}}
+	chain := cns.Chain{ChainName: "cosmos-hub", CosmosSDKVersion: "v0.44.3"}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set(logging.LoggerKey, zap.NewNop().Sugar())
+		c.Set(chains.ChainContextKey, chain)
+		c.Next()
+
+		if len(c.Errors) > 0 {
+			c.Status(http.StatusBadRequest)
+		}
+	})
+	engine.GET("/chain/:chain/block/:height", GetChainBlock(s, sdkservice.SDKServiceClients{"44": service}))
+
+	get := func(height string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/chain/cosmos-hub/block/"+height, nil))
+
+		return w
+	}
+
+	// a cache miss falls back to sdk-service, and fills the cache
+	w := get("10234")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, service.calls)
+
+	var res ChainBlockResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, int64(10234), res.Height)
+	require.Equal(t, 2, res.TxCount)
+
+	cached, err := m.Get("api-server/block/cosmos-hub/10234")
+	require.NoError(t, err)
+	require.Equal(t, grpcBlock, cached)
+
+	// a cache hit doesn't query sdk-service
+	w = get("10234")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, service.calls)
+
+	// failures aren't cached
+	require.Equal(t, http.StatusBadRequest, get("10235").Code)
+	require.False(t, m.Exists("api-server/block/cosmos-hub/10235"))
+
+	require.Equal(t, http.StatusBadRequest, get("latest").Code)
+	require.Equal(t, http.StatusBadRequest, get("0").Code)
+	require.Equal(t, 2, service.calls)
+}
@@ -1,6 +1,9 @@
 package block
 
 import (
+	"encoding/json"
+	"time"
+
 	coretypes "github.com/tendermint/tendermint/rpc/core/types"
 )
 
@@ -10,3 +13,14 @@ type BlockHeightResp struct {
 	ID      string                `json:"id"`
 	Result  coretypes.ResultBlock `json:"result"`
 }
+
+type ChainBlockResponse struct {
+	ChainName       string    `json:"chain_name"`
+	Height          int64     `json:"height"`
+	Hash            string    `json:"hash"`
+	Time            time.Time `json:"time"`
+	ProposerAddress string    `json:"proposer_address"`
+	TxCount         int       `json:"tx_count"`
+	// Block is the block as returned by the chain.
+	Block json.RawMessage `json:"block" swaggertype:"object"`
+}
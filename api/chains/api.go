@@ -30,21 +30,30 @@ func Register(router *gin.Engine, db *database.Database, cacheBackend CacheBacke
 		GET("/status", GetChainsStatuses(db)).
 		GET("/fee/addresses", GetFeeAddresses(db))
 
+	router.Group("/asset/:chain").
+		Use(GetChainMiddleware("chain", db)).
+		GET("/:base_denom/distribution", GetAssetDistribution(db, sdkServiceClients))
+
 	chain := router.Group("/chain/:chain")
 
 	chain.GET("/denom/verify_trace/:hash", VerifyTrace(db))
+	chain.POST("/denom/verify_traces", VerifyTraces(db))
 
 	chain.Group("").
 		Use(RequireChainEnabled("chain", db)).
 		GET("/primary_channels", GetPrimaryChannels(db)).
 		GET("/primary_channel/:counterparty", GetPrimaryChannelWithCounterparty(db)).
-		GET("/validators", GetValidators(db, cacheBackend))
+		GET("/validators/:operator/history", GetValidatorHistory(db))
 
 	chain.Use(GetChainMiddleware("chain", db)).
 		GET("", GetChain).
 		GET("/bech32", GetChainBech32Config).
+		GET("/denom/ibc/:hash", GetDenom(db)).
+		GET("/denom/:denom", GetDenom(db)).
 		GET("/status", GetChainStatus(db)).
-		GET("/supply", GetChainSupply(sdkServiceClients)).
+		GET("/validators", GetValidators(db, cacheBackend)).
+		GET("/validator/:address", GetValidator(db, cacheBackend)).
+		GET("/supply", GetChainSupply(db, cacheBackend, sdkServiceClients)).
 		GET("/supply/:denom", GetDenomSupply(sdkServiceClients)).
 		GET("/txs/:tx", GetChainTx(sdkServiceClients)).
 		GET("/numbers/:address", GetNumbersByAddress(sdkServiceClients)).
@@ -56,7 +65,13 @@ func Register(router *gin.Engine, db *database.Database, cacheBackend CacheBacke
 		GET("/apr", chainAPI.GetStakingAPR).
 		GET("/staking/pool", GetStakingPool(sdkServiceClients)).
 		GET("/distribution/params", GetDistributionParams(sdkServiceClients)).
-		GET("/budget/params", GetBudgetParams(sdkServiceClients))
+		GET("/budget/params", GetBudgetParams(sdkServiceClients)).
+		GET("/ibc/channels", GetIBCChannels(db)).
+		GET("/ibc/channels/:channel_id", GetIBCChannels(db)).
+		GET("/ibc/connections", GetIBCConnections(db)).
+		GET("/ibc/connections/:connection_id", GetIBCConnections(db)).
+		GET("/ibc/clients", GetIBCClients(db)).
+		GET("/ibc/clients/:client_id", GetIBCClients(db))
 
 	chain.Group("/fee").
 		GET("", GetFee(db)).
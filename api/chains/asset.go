@@ -0,0 +1,409 @@
+package chains
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/api/ibc"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	"github.com/emerishq/emeris-utils/logging"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+)
+
+// Names of the staking module accounts holding bonded and unbonding tokens.
+const (
+	bondedPoolName    = "bonded_tokens_pool"
+	notBondedPoolName = "not_bonded_tokens_pool"
+)
+
+type stakingPool struct {
+	NotBondedTokens string `json:"not_bonded_tokens"`
+	BondedTokens    string `json:"bonded_tokens"`
+}
+
+// assetVoucher is a denom trace of an asset on a chain other than its
+// origin chain.
+type assetVoucher struct {
+	chainName string
+	denom     string
+	path      string
+	verified  bool
+}
+
+// GetAssetDistribution returns where the supply of a native asset lives.
+// @Summary Gets the cross-chain distribution of an asset.
+// @Tags Chain
+// @ID asset-distribution
+// @Description Breaks the supply of a native asset down by location: balances held natively on its origin chain
+// @Description and as IBC vouchers on every enabled chain, along with the amount bonded on the origin chain.
+// @Description Tokens held by ICS-20 escrow accounts and staking module accounts are reported apart, so that
+// @Description circulating amounts can be summed up without counting the same tokens twice.
+// @Param chainName path string true "origin chain name"
+// @Param base_denom path string true "base denom of the asset on its origin chain"
+// @Produce json
+// @Success 200 {object} AssetDistributionResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /asset/{chainName}/{base_denom}/distribution [get]
+func GetAssetDistribution(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
+		baseDenom := c.Param("base_denom")
+
+		client, e := sdkServiceClients.GetSDKServiceClient(chain.MajorSDKVersion())
+		if e != nil {
+			_ = c.Error(e)
+			return
+		}
+
+		sdkRes, err := client.SupplyDenom(ctx, &sdkutilities.SupplyDenomPayload{
+			ChainName: chain.ChainName,
+			Denom:     &baseDenom,
+		})
+		if err != nil || len(sdkRes.Coins) != 1 {
+			if err == nil {
+				err = fmt.Errorf("expected 1 denom, found %v", sdkRes.Coins)
+			}
+
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve supply for chain: %s - denom: %s from sdk-service", chain.ChainName, baseDenom),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve denom supply from sdk-service: %w", err),
+				"chain name", chain.ChainName,
+				"denom name", baseDenom,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		supply, ok := parseAmount(sdkRes.Coins[0].Amount, baseDenom)
+		if !ok {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("invalid supply %s for chain: %s - denom: %s", sdkRes.Coins[0].Amount, chain.ChainName, baseDenom),
+				http.StatusInternalServerError,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		var pool *stakingPool
+		if d := findDenom(chain.Denoms, baseDenom); d != nil && d.Stakable {
+			poolRes, err := client.StakingPool(ctx, &sdkutilities.StakingPoolPayload{
+				ChainName: chain.ChainName,
+			})
+
+			var p struct {
+				Pool stakingPool `json:"pool"`
+			}
+			if err == nil {
+				err = json.Unmarshal(poolRes.StakingPool, &p)
+			}
+
+			if err != nil {
+				e := apierrors.New(
+					"chains",
+					fmt.Sprintf("cannot retrieve staking pool from sdk-service"),
+					http.StatusBadRequest,
+				).WithLogContext(
+					fmt.Errorf("cannot retrieve staking pool from sdk-service: %w", err),
+					"name",
+					chain.ChainName,
+				)
+				_ = c.Error(e)
+
+				return
+			}
+
+			pool = &p.Pool
+		}
+
+		traces, err := db.DenomTracesByBaseDenom(ctx, baseDenom)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve denom traces"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve denom traces: %w", err),
+				"base denom",
+				baseDenom,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		resolver := newTraceResolver(logger, db)
+		denoms := []string{baseDenom}
+		var vouchers []assetVoucher
+		for _, t := range traces {
+			trace, origin, err := resolver.resolveDenomTrace(ctx, t.ChainName, t)
+			if err != nil {
+				_ = c.Error(err)
+				return
+			}
+
+			if origin != nil && origin.ChainName != chain.ChainName {
+				// same base denom, but another asset
+				continue
+			}
+
+			// a voucher of unknown origin may as well be another asset
+			vouchers = append(vouchers, assetVoucher{
+				chainName: t.ChainName,
+				denom:     trace.IbcDenom.String(),
+				path:      t.Path,
+				verified:  trace.Verified && origin != nil,
+			})
+			denoms = append(denoms, trace.IbcDenom.String())
+		}
+
+		edges, err := db.IBCChannelEdges(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve channels"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve channel edges: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		totals, err := db.DenomTotals(ctx, denoms)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve balances"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve denom totals: %w", err),
+				"base denom",
+				baseDenom,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		escrows := escrowAddresses(edges)
+		addresses := []string{moduleAddress(bondedPoolName), moduleAddress(notBondedPoolName)}
+		for _, chainEscrows := range escrows {
+			for addr := range chainEscrows {
+				addresses = append(addresses, addr)
+			}
+		}
+
+		accountTotals, err := db.DenomTotalsByAddresses(ctx, denoms, addresses)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve balances"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve escrow and module account totals: %w", err),
+				"base denom",
+				baseDenom,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, AssetDistributionResponse{
+			Distribution: assetDistribution(chain.ChainName, baseDenom, supply, pool, vouchers, totals, accountTotals, escrows),
+		})
+	}
+}
+
+// moduleAddress returns the hex address of the module account called name.
+func moduleAddress(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(hash[:20])
+}
+
+// escrowAddresses returns the hex ICS-20 escrow addresses of every transfer
+// channel, indexed by chain name.
+func escrowAddresses(edges []database.IBCChannelEdge) map[string]map[string]bool {
+	ret := map[string]map[string]bool{}
+	for _, e := range edges {
+		if e.Port != "transfer" {
+			continue
+		}
+
+		if ret[e.ChainName] == nil {
+			ret[e.ChainName] = map[string]bool{}
+		}
+
+		ret[e.ChainName][hex.EncodeToString(ibc.EscrowAddress(e.Port, e.ChannelID))] = true
+	}
+
+	return ret
+}
+
+// parseAmount parses an amount which may or may not be suffixed by its denom.
+func parseAmount(amount, denom string) (sdktypes.Int, bool) {
+	return sdktypes.NewIntFromString(strings.TrimSuffix(amount, denom))
+}
+
+// assetDistribution breaks supply down by location out of the totals of the
+// asset on its origin chain and of its vouchers, and out of the ones held by
+// escrow and staking module accounts, accountTotals.
+// Unverified vouchers are listed but left out of the accounted amount.
+func assetDistribution(
+	originChain, baseDenom string,
+	supply sdktypes.Int,
+	pool *stakingPool,
+	vouchers []assetVoucher,
+	totals []database.DenomTotal,
+	accountTotals []database.DenomTotal,
+	escrows map[string]map[string]bool,
+) AssetDistribution {
+	type locationKey struct {
+		chainName string
+		denom     string
+	}
+
+	stakingAccounts := map[string]bool{
+		moduleAddress(bondedPoolName):    true,
+		moduleAddress(notBondedPoolName): true,
+	}
+
+	locations := map[locationKey]*AssetLocation{
+		{chainName: originChain, denom: baseDenom}: {
+			ChainName: originChain,
+			Denom:     baseDenom,
+			Native:    true,
+			Verified:  true,
+		},
+	}
+
+	for _, v := range vouchers {
+		locations[locationKey{chainName: v.chainName, denom: v.denom}] = &AssetLocation{
+			ChainName: v.chainName,
+			Denom:     v.denom,
+			Path:      v.path,
+			Verified:  v.verified,
+		}
+	}
+
+	type locationTotals struct {
+		amount, escrowed, staked sdktypes.Int
+		holders                  int
+	}
+
+	sums := map[locationKey]*locationTotals{}
+	for k := range locations {
+		sums[k] = &locationTotals{amount: sdktypes.ZeroInt(), escrowed: sdktypes.ZeroInt(), staked: sdktypes.ZeroInt()}
+	}
+
+	for _, t := range totals {
+		sum, ok := sums[locationKey{chainName: t.ChainName, denom: t.Denom}]
+		if !ok {
+			// total of a denom named the same on another chain
+			continue
+		}
+
+		amount, ok := sdktypes.NewIntFromString(t.Amount)
+		if !ok {
+			continue
+		}
+
+		sum.amount = amount
+		sum.holders = t.Holders
+	}
+
+	for _, t := range accountTotals {
+		sum, ok := sums[locationKey{chainName: t.ChainName, denom: t.Denom}]
+		if !ok {
+			continue
+		}
+
+		amount, ok := sdktypes.NewIntFromString(t.Amount)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case escrows[t.ChainName][t.Address]:
+			sum.escrowed = sum.escrowed.Add(amount)
+		case t.ChainName == originChain && stakingAccounts[t.Address]:
+			sum.staked = sum.staked.Add(amount)
+		}
+	}
+
+	ret := AssetDistribution{
+		ChainName: originChain,
+		BaseDenom: baseDenom,
+		Supply:    supply.String(),
+		Locations: make([]AssetLocation, 0, len(locations)),
+	}
+
+	accounted := sdktypes.ZeroInt()
+
+	if pool != nil {
+		ret.Bonded = pool.BondedTokens
+		ret.NotBonded = pool.NotBondedTokens
+
+		for _, staked := range []string{pool.BondedTokens, pool.NotBondedTokens} {
+			if amount, ok := sdktypes.NewIntFromString(staked); ok {
+				accounted = accounted.Add(amount)
+			}
+		}
+	}
+
+	for k, l := range locations {
+		sum := sums[k]
+		circulating := sum.amount.Sub(sum.escrowed).Sub(sum.staked)
+
+		l.Amount = sum.amount.String()
+		l.Escrowed = sum.escrowed.String()
+		l.Staked = sum.staked.String()
+		l.Circulating = circulating.String()
+		l.Holders = sum.holders
+
+		if l.Verified {
+			accounted = accounted.Add(circulating)
+		}
+
+		ret.Locations = append(ret.Locations, *l)
+	}
+
+	sort.Slice(ret.Locations, func(i, j int) bool {
+		if ret.Locations[i].Native != ret.Locations[j].Native {
+			return ret.Locations[i].Native
+		}
+
+		if ret.Locations[i].ChainName != ret.Locations[j].ChainName {
+			return ret.Locations[i].ChainName < ret.Locations[j].ChainName
+		}
+
+		return ret.Locations[i].Denom < ret.Locations[j].Denom
+	})
+
+	ret.Accounted = accounted.String()
+	ret.Unaccounted = supply.Sub(accounted).String()
+
+	return ret
+}
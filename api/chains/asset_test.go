@@ -0,0 +1,99 @@
+package chains
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleAddress(t *testing.T) {
+	addr := addrOf(t, "cosmos1fl48vsnmsdzcv85q5d2q4z5ajdha8yu34mf0eh")
+	require.Equal(t, hex.EncodeToString(addr), moduleAddress(bondedPoolName))
+}
+
+func TestAssetDistribution(t *testing.T) {
+	edges := []database.IBCChannelEdge{
+		{ChainName: "cosmos-hub", ChannelID: "channel-141", Port: "transfer"},
+		{ChainName: "osmosis", ChannelID: "channel-42", Port: "transfer"},
+		{ChainName: "osmosis", ChannelID: "channel-1", Port: "icahost"},
+	}
+	escrows := escrowAddresses(edges)
+	require.Len(t, escrows["osmosis"], 1)
+
+	hubEscrow := hex.EncodeToString(addrOf(t, "cosmos1x54ltnyg88k0ejmk8ytwrhd3ltm84xehrnlslf"))
+	require.True(t, escrows["cosmos-hub"][hubEscrow])
+
+	var osmoEscrow string
+	for addr := range escrows["osmosis"] {
+		osmoEscrow = addr
+	}
+
+	const (
+		osmoAtom = "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"
+		junoAtom = "ibc/C4CFF46FD6DE35CA4CF4CE031E643C8FDC9BA4B99AE598E9B0ED98FE3A2319F9"
+		fakeAtom = "ibc/0000000000000000000000000000000000000000000000000000000000000000"
+	)
+
+	vouchers := []assetVoucher{
+		{chainName: "osmosis", denom: osmoAtom, path: "transfer/channel-0", verified: true},
+		{chainName: "juno", denom: junoAtom, path: "transfer/channel-42/transfer/channel-0", verified: true},
+		{chainName: "juno", denom: fakeAtom, path: "transfer/channel-99", verified: false},
+	}
+
+	total := func(chainName, address, amount, denom string, holders int) database.DenomTotal {
+		return database.DenomTotal{
+			ChainName: chainName,
+			Address:   address,
+			Amount:    amount,
+			Denom:     denom,
+			Holders:   holders,
+		}
+	}
+
+	totals := []database.DenomTotal{
+		total("cosmos-hub", "", "950", "uatom", 3),
+		// a chain with a native denom called the same
+		total("other", "", "1000", "uatom", 1),
+		total("osmosis", "", "150", osmoAtom, 2),
+		total("juno", "", "40", junoAtom, 1),
+		total("juno", "", "7", fakeAtom, 1),
+	}
+
+	accountTotals := []database.DenomTotal{
+		total("cosmos-hub", moduleAddress(bondedPoolName), "300", "uatom", 1),
+		total("cosmos-hub", hubEscrow, "150", "uatom", 1),
+		total("osmosis", osmoEscrow, "40", osmoAtom, 1),
+		// staking module accounts only hold staked tokens on the origin chain
+		total("osmosis", moduleAddress(bondedPoolName), "10", osmoAtom, 1),
+	}
+
+	pool := &stakingPool{BondedTokens: "300", NotBondedTokens: "0"}
+
+	res := assetDistribution("cosmos-hub", "uatom", sdktypes.NewInt(1000), pool, vouchers, totals, accountTotals, escrows)
+
+	require.Equal(t, "1000", res.Supply)
+	require.Equal(t, "300", res.Bonded)
+	// 300 bonded + 500 on the hub + 110 on osmosis + 40 on juno
+	require.Equal(t, "950", res.Accounted)
+	require.Equal(t, "50", res.Unaccounted)
+
+	require.Equal(t, []AssetLocation{
+		{ChainName: "cosmos-hub", Denom: "uatom", Native: true, Verified: true, Amount: "950", Escrowed: "150", Staked: "300", Circulating: "500", Holders: 3},
+		{ChainName: "juno", Denom: fakeAtom, Path: "transfer/channel-99", Amount: "7", Escrowed: "0", Staked: "0", Circulating: "7", Holders: 1},
+		{ChainName: "juno", Denom: junoAtom, Path: "transfer/channel-42/transfer/channel-0", Verified: true, Amount: "40", Escrowed: "0", Staked: "0", Circulating: "40", Holders: 1},
+		{ChainName: "osmosis", Denom: osmoAtom, Path: "transfer/channel-0", Verified: true, Amount: "150", Escrowed: "40", Staked: "0", Circulating: "110", Holders: 2},
+	}, res.Locations)
+}
+
+func addrOf(t *testing.T, bech32Addr string) []byte {
+	t.Helper()
+
+	_, addr, err := bech32.DecodeAndConvert(bech32Addr)
+	require.NoError(t, err)
+
+	return addr
+}
@@ -2,8 +2,6 @@ package chains
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -34,6 +32,9 @@ const (
 	RetroCompatStagingDB = "retrocompatstagingdb"
 )
 
+// maxVerifyTraces is the maximum number of hashes accepted by VerifyTraces.
+const maxVerifyTraces = 100
+
 // GetChains returns the list of all the chains supported by demeris.
 // @Summary Gets list of supported chains.
 // @Tags Chain
@@ -232,241 +233,70 @@ func GetPrimaryChannels(db *database.Database) gin.HandlerFunc {
 func VerifyTrace(db *database.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
-		var res VerifiedTraceResponse
-
 		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
 
-		chainName := c.Param("chain")
-		hash := c.Param("hash")
-
-		res.VerifiedTrace.IbcDenom = IBCDenomHash(hash)
-
-		denomTrace, err := db.DenomTrace(ctx, chainName, hash)
-
+		trace, _, err := resolveTrace(ctx, logger, db, c.Param("chain"), c.Param("hash"))
 		if err != nil {
-			cause := fmt.Sprintf("token hash %v not found on chain %v", hash, chainName)
-
-			logger.Errorw(
-				cause,
-				"hash", hash,
-				"chainName", chainName,
-			)
-
-			res.VerifiedTrace.Verified = false
-			res.VerifiedTrace.Cause = cause
-
-			c.JSON(http.StatusOK, res)
+			_ = c.Error(err)
 			return
 		}
 
-		res.VerifiedTrace.Path = denomTrace.Path
-		res.VerifiedTrace.BaseDenom = denomTrace.BaseDenom
-
-		pathsElements, err := paths(res.VerifiedTrace.Path)
-
-		if err != nil {
+		c.JSON(http.StatusOK, VerifiedTraceResponse{VerifiedTrace: trace})
+	}
+}
 
-			cause := fmt.Sprintf("unsupported path %s", res.VerifiedTrace.Path)
+// VerifyTraces verifies many trace hashes against a chain name at once.
+// @Summary Verifies many trace hashes against a chain name.
+// @Tags Chain
+// @ID verifyTraces
+// @Description Verifies many trace hashes against a chain name, sharing chain and channel lookups between them.
+// @Param chainName path string true "chain name"
+// @Param traces body VerifyTracesRequest true "trace hashes to verify, case insensitive"
+// @Accept json
+// @Produce json
+// @Success 200 {object} VerifiedTracesResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/denom/verify_traces [post]
+func VerifyTraces(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		chainName := c.Param("chain")
 
-			logger.Errorw(
-				"invalid denom",
-				"hash", hash,
-				"path", res.VerifiedTrace.Path,
-				"err", cause,
+		var req VerifyTracesRequest
+		if err := c.BindJSON(&req); err != nil {
+			e := apierrors.New("denom/verify-traces", fmt.Sprintf("failed to parse JSON"), http.StatusBadRequest).WithLogContext(
+				fmt.Errorf("failed to parse JSON: %w", err),
 			)
-
-			res.VerifiedTrace.Verified = false
-			res.VerifiedTrace.Cause = cause
-
-			c.JSON(http.StatusOK, res)
+			_ = c.Error(e)
 
 			return
 		}
 
-		chainIDsMap, err := db.ChainIDs(ctx)
-
-		if err != nil {
-
-			err = fmt.Errorf("cannot query list of chain ids, %w", err)
-
+		if len(req.Hashes) > maxVerifyTraces {
 			e := apierrors.New(
-				"denom/verify-trace",
-				fmt.Sprintf("cannot query list of chain ids"),
+				"denom/verify-traces",
+				fmt.Sprintf("cannot verify more than %d traces at once", maxVerifyTraces),
 				http.StatusBadRequest,
-			).WithLogContext(
-				fmt.Errorf("cannot query list of chain ids: %w", err),
-				"hash",
-				hash,
-				"path",
-				res.VerifiedTrace.Path,
 			)
 			_ = c.Error(e)
+
 			return
 		}
 
-		nextChain := chainName
-		for _, element := range pathsElements {
-			// otherwise, check that it has a transfer prefix
-			if !strings.HasPrefix(element, "transfer/") {
-				cause := fmt.Sprintf("Unsupported path %s", res.VerifiedTrace.Path)
-
-				logger.Errorw(
-					"invalid denom",
-					"hash", hash,
-					"path", res.VerifiedTrace.Path,
-					"err", cause,
-				)
-
-				res.VerifiedTrace.Verified = false
-				res.VerifiedTrace.Cause = cause
-
-				c.JSON(http.StatusOK, res)
-
-				return
-			}
-
-			channel := strings.TrimPrefix(element, "transfer/")
-
-			var channelInfo cns.IbcChannelsInfo
-			var trace Trace
-
-			chainID, ok := chainIDsMap[nextChain]
-			if !ok {
-				logger.Errorw(
-					"cannot check path element during path resolution",
-					"hash", hash,
-					"path", res.VerifiedTrace.Path,
-					"err", fmt.Errorf("cannot find %s in chainIDs map", nextChain),
-				)
-
-				res.VerifiedTrace.Verified = false
-				res.VerifiedTrace.Cause = "cannot check path element during path resolution"
-
-				c.JSON(http.StatusOK, res)
-
-				return
-			}
-
-			channelInfo, err = db.GetIbcChannelToChain(ctx, nextChain, channel, chainID)
-
-			if err != nil {
-				if errors.As(err, &database.ErrNoDestChain{}) {
-					logger.Errorw(
-						err.Error(),
-						"hash", hash,
-						"path", res.VerifiedTrace.Path,
-						"chain", chainName,
-					)
-
-					res.VerifiedTrace.Verified = false
-					res.VerifiedTrace.Cause = err.Error()
-
-					c.JSON(http.StatusOK, res)
-				} else {
-					e1 := apierrors.New(
-						"denom/verify-trace",
-						fmt.Sprintf("failed querying for %s, error: %v", hash, err),
-						http.StatusBadRequest,
-					).WithLogContext(
-						fmt.Errorf("invalid number of query responses: %w", err),
-						"hash",
-						hash,
-					)
-					_ = c.Error(e1)
-				}
-
-				return
-			}
-
-			trace.ChainName = channelInfo[0].ChainAName
-			trace.CounterpartyName = channelInfo[0].ChainBName
-			trace.Channel = channelInfo[0].ChainAChannelID
-			trace.Port = "transfer"
-
-			res.VerifiedTrace.Trace = append(res.VerifiedTrace.Trace, trace)
-
-			nextChain = trace.CounterpartyName
+		resolver := newTraceResolver(logger, db)
+		res := VerifiedTracesResponse{
+			VerifiedTraces: make([]VerifiedTrace, 0, len(req.Hashes)),
 		}
 
-		nextChainData, err := db.Chain(ctx, nextChain)
-		if err != nil {
-			logger.Errorw(
-				"cannot query chain",
-				"hash", hash,
-				"path", res.VerifiedTrace.Path,
-				"nextChain", nextChain,
-				"err", err,
-			)
-
-			// we did not find any chain with name nextChain
-			if errors.Is(err, sql.ErrNoRows) {
-				res.VerifiedTrace.Verified = false
-				res.VerifiedTrace.Cause = fmt.Sprintf("no chain with name %s found", nextChain)
-				c.JSON(http.StatusOK, res)
-
+		for _, hash := range req.Hashes {
+			trace, _, err := resolver.resolve(ctx, chainName, hash)
+			if err != nil {
+				_ = c.Error(err)
 				return
 			}
 
-			e := apierrors.New(
-				"denom/verify-trace",
-				fmt.Sprintf("database error, %v", err),
-				http.StatusInternalServerError,
-			).WithLogContext(
-				fmt.Errorf("cannot query chain with name: %w", err),
-				"hash",
-				hash,
-				"path",
-				res.VerifiedTrace.Path,
-				"chain",
-				chainName,
-				"nextChain",
-				nextChain,
-			)
-			_ = c.Error(e)
-
-			return
-		}
-
-		cbt, err := db.ChainLastBlock(ctx, nextChain)
-		if err != nil {
-			e := apierrors.New(
-				"denom/verify-trace",
-				fmt.Sprintf("cannot retrieve chain status for %v", nextChain),
-				http.StatusInternalServerError,
-			).WithLogContext(
-				fmt.Errorf("cannot retrieve chain last block time: %w", err),
-				"hash",
-				hash,
-				"path",
-				res.VerifiedTrace.Path,
-				"chainName",
-				chainName,
-				"nextChain",
-				nextChain,
-			)
-			_ = c.Error(e)
-
-			return
-		}
-
-		logger.Debugw("last block time", "chain", nextChain, "time", cbt, "threshold_for_chain", nextChainData.ValidBlockThresh.Duration())
-
-		if time.Since(cbt.BlockTime) > nextChainData.ValidBlockThresh.Duration() {
-			res.VerifiedTrace.Verified = false
-			res.VerifiedTrace.Cause = fmt.Sprintf("chain %s status offline", nextChain)
-			c.JSON(http.StatusOK, res)
-
-			return
-		}
-
-		res.VerifiedTrace.Verified = false
-
-		// set verifiedStatus for base denom on nextChain
-		for _, d := range nextChainData.Denoms {
-			if denomTrace.BaseDenom == d.Name {
-				res.VerifiedTrace.Verified = d.Verified
-				break
-			}
+			res.VerifiedTraces = append(res.VerifiedTraces, trace)
 		}
 
 		c.JSON(http.StatusOK, res)
@@ -555,12 +385,17 @@ func GetChainStatus(db *database.Database) gin.HandlerFunc {
 // @Tags Chain
 // @ID supply
 // @Description Gets supply of a given chain.
+// @Description When aggregate is true, every supply page is fetched server-side and each denom
+// @Description is enriched with its IBC trace, verification status and display units.
 // @Param chainName path string true "chain name"
+// @Param key query string false "pagination key, ignored when aggregate is true"
+// @Param aggregate query bool false "walk every supply page and enrich denoms"
 // @Produce json
 // @Success 200 {object} SupplyResponse
+// @Success 200 {object} AggregatedSupplyResponse
 // @Failure 500,403 {object} apierrors.UserFacingError
 // @Router /chain/{chainName}/supply [get]
-func GetChainSupply(sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+func GetChainSupply(db *database.Database, cacheBackend CacheBackend, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 		paginationKey, exists := c.GetQuery("key")
@@ -572,6 +407,36 @@ func GetChainSupply(sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerF
 			return
 		}
 
+		if c.Query("aggregate") == "true" {
+			logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+			supplyCache := stringcache.NewStringCache(
+				logger,
+				cacheBackend,
+				aggregatedSupplyCacheDuration,
+				aggregatedSupplyCachePrefix,
+				aggregatedSupplyHandler(logger, db, client, chain),
+			)
+
+			supply, err := supplyCache.Get(ctx, chain.ChainName, false)
+			if err != nil {
+				e := apierrors.New(
+					"chains",
+					fmt.Sprintf("cannot retrieve aggregated supply"),
+					http.StatusBadRequest,
+				).WithLogContext(
+					fmt.Errorf("cannot retrieve aggregated supply: %w", err),
+					"name",
+					chain.ChainName,
+				)
+				_ = c.Error(e)
+
+				return
+			}
+
+			c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(supply))
+			return
+		}
+
 		payload := &sdkutilities.SupplyPayload{
 			ChainName: chain.ChainName,
 		}
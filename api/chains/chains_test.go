@@ -26,12 +26,13 @@ import (
 )
 
 const (
-	chainEndpointUrl       = "http://%s/chain/%s"
-	chainsEndpointUrl      = "http://%s/chains"
-	chainsStatusesUrl      = "http://%s/chains/status"
-	chainStatusUrl         = "http://%s/chain/%s/status"
-	chainSupplyUrl         = "http://%s/chain/%s/supply"
-	verifyTraceEndpointUrl = "http://%s/chain/%s/denom/verify_trace/%s"
+	chainEndpointUrl        = "http://%s/chain/%s"
+	chainsEndpointUrl       = "http://%s/chains"
+	chainsStatusesUrl       = "http://%s/chains/status"
+	chainStatusUrl          = "http://%s/chain/%s/status"
+	chainSupplyUrl          = "http://%s/chain/%s/supply"
+	verifyTraceEndpointUrl  = "http://%s/chain/%s/denom/verify_trace/%s"
+	verifyTracesEndpointUrl = "http://%s/chain/%s/denom/verify_traces"
 )
 
 func TestGetChain(t *testing.T) {
@@ -193,6 +194,35 @@ func TestVerifyTrace(t *testing.T) {
 	}
 }
 
+func TestVerifyTraces(t *testing.T) {
+	utils.RunTraceListnerMigrations(testingCtx, t)
+	utils.InsertTraceListnerData(testingCtx, t, utils.VerifyTraceData)
+	for _, chain := range []cns.Chain{utils.ChainWithPublicEndpoints, utils.ChainWithoutPublicEndpoints} {
+		require.NoError(t, testingCtx.CnsDB.AddChain(chain))
+	}
+	defer utils.TruncateTracelistener(testingCtx, t)
+	defer utils.TruncateCNSDB(testingCtx, t)
+
+	body := `{"hashes": ["abc12345", "xyz", "ABC12345"]}`
+	resp, err := http.Post(fmt.Sprintf(verifyTracesEndpointUrl, testingCtx.Cfg.ListenAddr, "chain1"), "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var res chains.VerifiedTracesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	require.Len(t, res.VerifiedTraces, 3)
+
+	require.True(t, res.VerifiedTraces[0].Verified, "result cause=%s", res.VerifiedTraces[0].Cause)
+	require.Equal(t, "transfer", res.VerifiedTraces[0].Trace[0].Port)
+
+	require.False(t, res.VerifiedTraces[1].Verified)
+	require.Contains(t, res.VerifiedTraces[1].Cause, "token hash xyz not found on chain chain1")
+
+	require.True(t, res.VerifiedTraces[2].Verified, "result cause=%s", res.VerifiedTraces[2].Cause)
+}
+
 func TestGetChainStatus(t *testing.T) {
 	utils.RunTraceListnerMigrations(testingCtx, t)
 	utils.InsertTraceListnerData(testingCtx, t, utils.VerifyTraceData)
@@ -92,6 +92,29 @@ var verifyTraceTestCases = []struct {
 		true,
 		200,
 	},
+	{
+		"chain1->wasm port ch1->Chain2",
+		utils.TracelistenerData{
+			Denoms: []utils.DenomTrace{
+				{
+					Path:      "wasm.contract1/ch1",
+					BaseDenom: "denom2",
+					Hash:      "abc12345",
+					ChainName: "chain1",
+				},
+			},
+			Channels:    utils.VerifyTraceData.Channels,
+			Connections: utils.VerifyTraceData.Connections,
+			Clients:     utils.VerifyTraceData.Clients,
+			BlockTimes:  utils.VerifyTraceData.BlockTimes,
+		},
+		[]cns.Chain{utils.ChainWithPublicEndpoints, utils.ChainWithoutPublicEndpoints},
+		"chain1",
+		"abc12345",
+		"",
+		true,
+		200,
+	},
 	{
 		"wrong hash",
 		utils.VerifyTraceData,
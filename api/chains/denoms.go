@@ -0,0 +1,141 @@
+package chains
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/logging"
+)
+
+// GetDenom returns everything known about a denom of a given chain.
+// @Summary Gets metadata of a denom of a given chain.
+// @Tags Chain
+// @ID denom
+// @Description Gets CNS metadata of a native denom, or the full trace and origin chain metadata of an IBC denom.
+// @Description IBC denoms are requested as /chain/{chainName}/denom/ibc/{hash}.
+// @Param chainName path string true "chain name"
+// @Param denom path string true "denom name"
+// @Produce json
+// @Success 200 {object} DenomResponse
+// @Failure 500,404,400 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/denom/{denom} [get]
+func GetDenom(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
+
+		hash := c.Param("hash")
+		if hash == "" {
+			denom := c.Param("denom")
+
+			info, ok := nativeDenomInfo(chain, denom)
+			if !ok {
+				e := apierrors.New(
+					"denom",
+					fmt.Sprintf("denom %v not found on chain %v", denom, chain.ChainName),
+					http.StatusNotFound,
+				)
+				_ = c.Error(e)
+				return
+			}
+
+			c.JSON(http.StatusOK, DenomResponse{
+				Denom: info,
+			})
+			return
+		}
+
+		denomTrace, err := db.DenomTrace(ctx, chain.ChainName, hash)
+		if errors.Is(err, sql.ErrNoRows) {
+			e := apierrors.New(
+				"denom",
+				fmt.Sprintf("token hash %v not found on chain %v", hash, chain.ChainName),
+				http.StatusNotFound,
+			)
+			_ = c.Error(e)
+			return
+		}
+
+		if err != nil {
+			e := apierrors.New(
+				"denom",
+				fmt.Sprintf("cannot retrieve denom trace"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve denom trace: %w", err),
+				"hash",
+				hash,
+				"chain",
+				chain.ChainName,
+			)
+			_ = c.Error(e)
+			return
+		}
+
+		trace, origin, err := newTraceResolver(logger, db).resolveDenomTrace(ctx, chain.ChainName, denomTrace)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, DenomResponse{
+			Denom: ibcDenomInfo(chain, trace, origin),
+		})
+	}
+}
+
+// nativeDenomInfo returns the DenomInfo of denom, or false if it isn't one of
+// the CNS denoms of chain.
+func nativeDenomInfo(chain cns.Chain, denom string) (DenomInfo, bool) {
+	metadata := findDenom(chain.Denoms, denom)
+	if metadata == nil {
+		return DenomInfo{}, false
+	}
+
+	return DenomInfo{
+		Denom:       denom,
+		ChainName:   chain.ChainName,
+		BaseDenom:   denom,
+		Native:      true,
+		OriginChain: chain.ChainName,
+		Metadata:    metadata,
+	}, true
+}
+
+func ibcDenomInfo(chain cns.Chain, trace VerifiedTrace, origin *cns.Chain) DenomInfo {
+	ret := DenomInfo{
+		Denom:     trace.IbcDenom.String(),
+		ChainName: chain.ChainName,
+		BaseDenom: trace.BaseDenom,
+		Trace:     &trace,
+	}
+
+	if origin != nil {
+		ret.OriginChain = origin.ChainName
+		ret.Metadata = findDenom(origin.Denoms, trace.BaseDenom)
+	}
+
+	return ret
+}
+
+// findDenom returns the denom called name in denoms, or nil if there's none.
+func findDenom(denoms cns.DenomList, name string) *cns.Denom {
+	for _, d := range denoms {
+		if d.Name == name {
+			d := d
+			return &d
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenomInfo(t *testing.T) {
+	atom := cns.Denom{Name: "uatom", DisplayName: "ATOM", Ticker: "ATOM", Precision: 6, FeeToken: true, Verified: true}
+	osmo := cns.Denom{Name: "uosmo", DisplayName: "OSMO", Ticker: "OSMO", Precision: 6, RelayerDenom: true, Verified: true}
+	cosmos := cns.Chain{ChainName: "cosmos-hub", Denoms: cns.DenomList{atom}}
+	osmosis := cns.Chain{ChainName: "osmosis", Denoms: cns.DenomList{osmo}}
+
+	t.Run("native denom", func(t *testing.T) {
+		res, ok := nativeDenomInfo(cosmos, "uatom")
+		require.True(t, ok)
+		require.True(t, res.Native)
+		require.Equal(t, "cosmos-hub", res.OriginChain)
+		require.Equal(t, &atom, res.Metadata)
+		require.Nil(t, res.Trace)
+	})
+
+	t.Run("unknown native denom", func(t *testing.T) {
+		_, ok := nativeDenomInfo(cosmos, "ufoo")
+		require.False(t, ok)
+	})
+
+	t.Run("resolved ibc denom", func(t *testing.T) {
+		trace := VerifiedTrace{
+			IbcDenom:  IBCDenomHash("abc"),
+			BaseDenom: "uosmo",
+			Verified:  true,
+			Path:      "transfer/channel-0",
+			Trace:     []Trace{{Channel: "channel-0", Port: "transfer", ChainName: "cosmos-hub", CounterpartyName: "osmosis"}},
+		}
+		res := ibcDenomInfo(cosmos, trace, &osmosis)
+		require.False(t, res.Native)
+		require.Equal(t, "ibc/ABC", res.Denom)
+		require.Equal(t, "osmosis", res.OriginChain)
+		require.Equal(t, &osmo, res.Metadata)
+		require.Equal(t, &trace, res.Trace)
+	})
+
+	t.Run("unresolved ibc denom", func(t *testing.T) {
+		trace := VerifiedTrace{IbcDenom: IBCDenomHash("abc"), Cause: "token hash abc not found on chain cosmos-hub"}
+		res := ibcDenomInfo(cosmos, trace, nil)
+		require.Empty(t, res.OriginChain)
+		require.Nil(t, res.Metadata)
+		require.Equal(t, trace.Cause, res.Trace.Cause)
+	})
+}
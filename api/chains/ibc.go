@@ -0,0 +1,277 @@
+package chains
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/api/ibc"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+)
+
+// GetIBCChannels returns the IBC channels of a given chain.
+// @Summary Gets IBC channels of a chain.
+// @Tags Chain
+// @ID ibc-channels
+// @Description Gets IBC channels of a chain, along with their connection, client and resolved counterparty chain.
+// @Param chainName path string true "chain name"
+// @Param channel_id path string false "channel id, when querying a single channel"
+// @Param port query string false "port filter"
+// @Param counterparty_chain_id query string false "counterparty chain id filter"
+// @Produce json
+// @Success 200 {object} IBCChannelsResponse
+// @Failure 500,400,404 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/ibc/channels [get]
+// @Router /chain/{chainName}/ibc/channels/{channel_id} [get]
+func GetIBCChannels(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
+
+		edges, err := db.ChainIBCChannelEdges(ctx, chain.ChainName)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve channels"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve channel edges: %w", err),
+				"name",
+				chain.ChainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		res := IBCChannelsResponse{
+			Channels: filterIBCChannels(edges, c.Param("channel_id"), c.Query("port"), c.Query("counterparty_chain_id")),
+		}
+
+		if !requireFound(c, "channel", c.Param("channel_id"), len(res.Channels)) {
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// GetIBCConnections returns the IBC connections of a given chain.
+// @Summary Gets IBC connections of a chain.
+// @Tags Chain
+// @ID ibc-connections
+// @Description Gets IBC connections of a chain, along with their resolved counterparty chain.
+// @Param chainName path string true "chain name"
+// @Param connection_id path string false "connection id, when querying a single connection"
+// @Param counterparty_chain_id query string false "counterparty chain id filter"
+// @Produce json
+// @Success 200 {object} IBCConnectionsResponse
+// @Failure 500,400,404 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/ibc/connections [get]
+// @Router /chain/{chainName}/ibc/connections/{connection_id} [get]
+func GetIBCConnections(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
+
+		connections, err := db.Connections(ctx, chain.ChainName)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve connections"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve connections: %w", err),
+				"name",
+				chain.ChainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		chainNames, ok := chainNamesByID(c, db)
+		if !ok {
+			return
+		}
+
+		res := IBCConnectionsResponse{
+			Connections: filterIBCConnections(connections, chainNames, c.Param("connection_id"), c.Query("counterparty_chain_id")),
+		}
+
+		if !requireFound(c, "connection", c.Param("connection_id"), len(res.Connections)) {
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// GetIBCClients returns the IBC clients of a given chain.
+// @Summary Gets IBC clients of a chain.
+// @Tags Chain
+// @ID ibc-clients
+// @Description Gets IBC clients of a chain, along with the name of the chain they track.
+// @Param chainName path string true "chain name"
+// @Param client_id path string false "client id, when querying a single client"
+// @Param counterparty_chain_id query string false "counterparty chain id filter"
+// @Produce json
+// @Success 200 {object} IBCClientsResponse
+// @Failure 500,400,404 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/ibc/clients [get]
+// @Router /chain/{chainName}/ibc/clients/{client_id} [get]
+func GetIBCClients(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
+
+		clients, err := db.Clients(ctx, chain.ChainName)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve clients"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve clients: %w", err),
+				"name",
+				chain.ChainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		chainNames, ok := chainNamesByID(c, db)
+		if !ok {
+			return
+		}
+
+		res := IBCClientsResponse{
+			Clients: filterIBCClients(clients, chainNames, c.Param("client_id"), c.Query("counterparty_chain_id")),
+		}
+
+		if !requireFound(c, "client", c.Param("client_id"), len(res.Clients)) {
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// chainNamesByID returns the enabled chain names indexed by chain ID.
+// On failure the error is set on c and false is returned.
+func chainNamesByID(c *gin.Context, db *database.Database) (map[string]string, bool) {
+	chainIDs, err := db.ChainIDs(c.Request.Context())
+	if err != nil {
+		e := apierrors.New(
+			"chains",
+			fmt.Sprintf("cannot retrieve chain ids"),
+			http.StatusInternalServerError,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain ids: %w", err),
+		)
+		_ = c.Error(e)
+
+		return nil, false
+	}
+
+	ret := make(map[string]string, len(chainIDs))
+	for name, id := range chainIDs {
+		ret[id] = name
+	}
+
+	return ret, true
+}
+
+// requireFound sets a not found error on c if a lookup by id returned no
+// result.
+func requireFound(c *gin.Context, kind, id string, n int) bool {
+	if id == "" || n > 0 {
+		return true
+	}
+
+	e := apierrors.New(
+		"chains",
+		fmt.Sprintf("%s %s not found", kind, id),
+		http.StatusNotFound,
+	)
+	_ = c.Error(e)
+
+	return false
+}
+
+func filterIBCChannels(edges []database.IBCChannelEdge, channelID, port, counterpartyChainID string) []IBCChannel {
+	ret := make([]IBCChannel, 0, len(edges))
+	for _, e := range edges {
+		ch := IBCChannel{
+			ChannelID:           e.ChannelID,
+			CounterChannelID:    e.CounterChannelID,
+			Port:                e.Port,
+			State:               ibc.ChannelStateName(e.State),
+			ConnectionID:        apiutils.StringValue(e.ConnectionID),
+			ClientID:            apiutils.StringValue(e.ClientID),
+			CounterpartyChainID: apiutils.StringValue(e.CounterpartyChainID),
+			CounterpartyName:    apiutils.StringValue(e.CounterpartyChainName),
+		}
+
+		if (channelID != "" && ch.ChannelID != channelID) ||
+			(port != "" && ch.Port != port) ||
+			(counterpartyChainID != "" && ch.CounterpartyChainID != counterpartyChainID) {
+			continue
+		}
+
+		ret = append(ret, ch)
+	}
+
+	return ret
+}
+
+func filterIBCConnections(connections []database.IBCConnection, chainNames map[string]string, connectionID, counterpartyChainID string) []IBCConnection {
+	ret := make([]IBCConnection, 0, len(connections))
+	for _, conn := range connections {
+		ic := IBCConnection{
+			ConnectionID:        conn.ConnectionID,
+			ClientID:            conn.ClientID,
+			State:               conn.State,
+			CounterConnectionID: conn.CounterConnectionID,
+			CounterClientID:     conn.CounterClientID,
+			CounterpartyChainID: apiutils.StringValue(conn.CounterpartyChainID),
+		}
+		ic.CounterpartyName = chainNames[ic.CounterpartyChainID]
+
+		if (connectionID != "" && ic.ConnectionID != connectionID) ||
+			(counterpartyChainID != "" && ic.CounterpartyChainID != counterpartyChainID) {
+			continue
+		}
+
+		ret = append(ret, ic)
+	}
+
+	return ret
+}
+
+func filterIBCClients(clients []tracelistener.IBCClientStateRow, chainNames map[string]string, clientID, counterpartyChainID string) []IBCClient {
+	ret := make([]IBCClient, 0, len(clients))
+	for _, cl := range clients {
+		if (clientID != "" && cl.ClientID != clientID) ||
+			(counterpartyChainID != "" && cl.ChainID != counterpartyChainID) {
+			continue
+		}
+
+		ret = append(ret, IBCClient{
+			ClientID:            cl.ClientID,
+			CounterpartyChainID: cl.ChainID,
+			CounterpartyName:    chainNames[cl.ChainID],
+			LatestHeight:        cl.LatestHeight,
+			TrustingPeriod:      cl.TrustingPeriod,
+		})
+	}
+
+	return ret
+}
@@ -0,0 +1,79 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterIBCChannels(t *testing.T) {
+	edges := []database.IBCChannelEdge{
+		{ChainName: "cosmos-hub", ChannelID: "channel-0", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("osmosis-1"), CounterpartyChainName: apiutils.StringPtr("osmosis")},
+		{ChainName: "cosmos-hub", ChannelID: "channel-1", Port: "icahost", State: 3, CounterpartyChainID: apiutils.StringPtr("osmosis-1")},
+		{ChainName: "cosmos-hub", ChannelID: "channel-2", Port: "transfer", State: 4, CounterpartyChainID: apiutils.StringPtr("juno-1")},
+	}
+
+	tests := []struct {
+		name                string
+		channelID           string
+		port                string
+		counterpartyChainID string
+		expected            []string
+	}{
+		{"no filter", "", "", "", []string{"channel-0", "channel-1", "channel-2"}},
+		{"by id", "channel-1", "", "", []string{"channel-1"}},
+		{"by port", "", "transfer", "", []string{"channel-0", "channel-2"}},
+		{"by counterparty", "", "", "osmosis-1", []string{"channel-0", "channel-1"}},
+		{"by port and counterparty", "", "transfer", "osmosis-1", []string{"channel-0"}},
+		{"unknown id", "channel-9", "", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := filterIBCChannels(edges, tt.channelID, tt.port, tt.counterpartyChainID)
+			ids := make([]string, 0, len(res))
+			for _, ch := range res {
+				ids = append(ids, ch.ChannelID)
+			}
+
+			require.Equal(t, tt.expected, ids)
+		})
+	}
+
+	res := filterIBCChannels(edges, "channel-0", "", "")
+	require.Equal(t, "osmosis", res[0].CounterpartyName)
+	require.Equal(t, "STATE_OPEN", res[0].State)
+}
+
+func TestFilterIBCConnectionsAndClients(t *testing.T) {
+	chainNames := map[string]string{"osmosis-1": "osmosis"}
+
+	connections := []database.IBCConnection{
+		{IBCConnectionRow: tracelistener.IBCConnectionRow{ConnectionID: "connection-0", ClientID: "07-tendermint-0"}, CounterpartyChainID: apiutils.StringPtr("osmosis-1")},
+		{IBCConnectionRow: tracelistener.IBCConnectionRow{ConnectionID: "connection-1", ClientID: "07-tendermint-1"}},
+	}
+
+	conns := filterIBCConnections(connections, chainNames, "", "osmosis-1")
+	require.Len(t, conns, 1)
+	require.Equal(t, "connection-0", conns[0].ConnectionID)
+	require.Equal(t, "osmosis", conns[0].CounterpartyName)
+
+	require.Len(t, filterIBCConnections(connections, chainNames, "connection-1", ""), 1)
+
+	clients := []tracelistener.IBCClientStateRow{
+		{ClientID: "07-tendermint-0", ChainID: "osmosis-1", LatestHeight: 42},
+		{ClientID: "07-tendermint-1", ChainID: "juno-1"},
+	}
+
+	cls := filterIBCClients(clients, chainNames, "07-tendermint-0", "")
+	require.Len(t, cls, 1)
+	require.Equal(t, "osmosis", cls[0].CounterpartyName)
+	require.Equal(t, uint64(42), cls[0].LatestHeight)
+
+	cls = filterIBCClients(clients, chainNames, "", "juno-1")
+	require.Len(t, cls, 1)
+	require.Empty(t, cls[0].CounterpartyName)
+}
@@ -103,6 +103,69 @@ type VerifiedTraceResponse struct {
 	VerifiedTrace VerifiedTrace `json:"verify_trace"`
 }
 
+type DenomInfo struct {
+	Denom       string         `json:"denom"`
+	ChainName   string         `json:"chain_name"`
+	BaseDenom   string         `json:"base_denom,omitempty"`
+	Native      bool           `json:"native"`
+	OriginChain string         `json:"origin_chain,omitempty"`
+	Metadata    *cns.Denom     `json:"metadata,omitempty"`
+	Trace       *VerifiedTrace `json:"trace,omitempty"`
+}
+
+type DenomResponse struct {
+	Denom DenomInfo `json:"denom"`
+}
+
+type VerifyTracesRequest struct {
+	Hashes []string `json:"hashes" binding:"required"`
+}
+
+type VerifiedTracesResponse struct {
+	VerifiedTraces []VerifiedTrace `json:"verify_traces"`
+}
+
+type IBCChannel struct {
+	ChannelID           string `json:"channel_id"`
+	CounterChannelID    string `json:"counter_channel_id"`
+	Port                string `json:"port"`
+	State               string `json:"state"`
+	ConnectionID        string `json:"connection_id,omitempty"`
+	ClientID            string `json:"client_id,omitempty"`
+	CounterpartyChainID string `json:"counterparty_chain_id,omitempty"`
+	CounterpartyName    string `json:"counterparty_name,omitempty"`
+}
+
+type IBCChannelsResponse struct {
+	Channels []IBCChannel `json:"channels"`
+}
+
+type IBCConnection struct {
+	ConnectionID        string `json:"connection_id"`
+	ClientID            string `json:"client_id"`
+	State               string `json:"state"`
+	CounterConnectionID string `json:"counter_connection_id"`
+	CounterClientID     string `json:"counter_client_id"`
+	CounterpartyChainID string `json:"counterparty_chain_id,omitempty"`
+	CounterpartyName    string `json:"counterparty_name,omitempty"`
+}
+
+type IBCConnectionsResponse struct {
+	Connections []IBCConnection `json:"connections"`
+}
+
+type IBCClient struct {
+	ClientID            string `json:"client_id"`
+	CounterpartyChainID string `json:"counterparty_chain_id"`
+	CounterpartyName    string `json:"counterparty_name,omitempty"`
+	LatestHeight        uint64 `json:"latest_height"`
+	TrustingPeriod      int64  `json:"trusting_period"`
+}
+
+type IBCClientsResponse struct {
+	Clients []IBCClient `json:"clients"`
+}
+
 type StatusResponse struct {
 	Online bool `json:"online"`
 }
@@ -117,7 +180,43 @@ type ValidatorsResponse struct {
 
 type Validator struct {
 	tracelistener.ValidatorRow
-	Avatar string `json:"avatar,omitempty"`
+	Avatar           string `json:"avatar,omitempty"`
+	ConsensusAddress string `json:"consensus_address,omitempty"`
+	AccountAddress   string `json:"account_address,omitempty"`
+}
+
+type ValidatorResponse struct {
+	Validator *Validator `json:"validator"`
+}
+
+// ValidatorEventType is the kind of change detected between two consecutive
+// tracelistener rows of the same validator.
+type ValidatorEventType string
+
+const (
+	ValidatorEventCommissionChange ValidatorEventType = "commission_change"
+	ValidatorEventJailed           ValidatorEventType = "jailed"
+	ValidatorEventUnjailed         ValidatorEventType = "unjailed"
+	ValidatorEventStatusChange     ValidatorEventType = "status_change"
+	ValidatorEventPossibleSlash    ValidatorEventType = "possible_slash"
+)
+
+type ValidatorEvent struct {
+	Type       ValidatorEventType `json:"type"`
+	Height     uint64             `json:"height"`
+	UpdateTime string             `json:"update_time,omitempty"`
+	From       string             `json:"from,omitempty"`
+	To         string             `json:"to,omitempty"`
+}
+
+type ValidatorHistoryResponse struct {
+	OperatorAddress string           `json:"operator_address"`
+	Events          []ValidatorEvent `json:"events"`
+
+	// CommissionIncreased and Jailed summarize the events so that clients can
+	// warn delegators without walking the whole timeline.
+	CommissionIncreased bool `json:"commission_increased"`
+	Jailed              bool `json:"jailed"`
 }
 
 // nolint :ditto
@@ -147,6 +246,22 @@ type SupplyResponse struct {
 	Pagination Pagination `json:"pagination,omitempty"`
 }
 
+type AggregatedSupplyResponse struct {
+	Supply []SupplyCoin `json:"supply"`
+}
+
+type SupplyCoin struct {
+	Denom         string `json:"denom"`
+	Amount        string `json:"amount"`
+	BaseDenom     string `json:"base_denom"`
+	Path          string `json:"path,omitempty"`
+	Verified      bool   `json:"verified"`
+	DisplayName   string `json:"display_name,omitempty"`
+	Ticker        string `json:"ticker,omitempty"`
+	Precision     int64  `json:"precision,omitempty"`
+	DisplayAmount string `json:"display_amount,omitempty"`
+}
+
 type Pagination struct {
 	NextKey string `json:"next_key,omitempty"`
 	Total   string `json:"total,omitempty"`
@@ -169,3 +284,38 @@ func NewChainsStatusesResponse(sz int) ChainsStatusesResponse {
 		Chains: make(map[string]ChainStatus, sz),
 	}
 }
+
+type AssetDistributionResponse struct {
+	Distribution AssetDistribution `json:"distribution"`
+}
+
+// AssetDistribution breaks the supply of a native asset down by location.
+// Accounted sums up the bonded, unbonding and circulating amounts of every
+// verified location, Unaccounted is what's left of Supply.
+type AssetDistribution struct {
+	ChainName   string          `json:"chain_name"`
+	BaseDenom   string          `json:"base_denom"`
+	Supply      string          `json:"supply"`
+	Bonded      string          `json:"bonded,omitempty"`
+	NotBonded   string          `json:"not_bonded,omitempty"`
+	Accounted   string          `json:"accounted"`
+	Unaccounted string          `json:"unaccounted"`
+	Locations   []AssetLocation `json:"locations"`
+}
+
+// AssetLocation is the amount of an asset held on a chain, either natively
+// or as an IBC voucher.
+// Escrowed is held by ICS-20 escrow accounts and backs vouchers on other
+// chains, Staked is held by the staking module accounts of the origin chain.
+type AssetLocation struct {
+	ChainName   string `json:"chain_name"`
+	Denom       string `json:"denom"`
+	Path        string `json:"path,omitempty"`
+	Native      bool   `json:"native"`
+	Verified    bool   `json:"verified"`
+	Amount      string `json:"amount"`
+	Escrowed    string `json:"escrowed"`
+	Staked      string `json:"staked"`
+	Circulating string `json:"circulating"`
+	Holders     int    `json:"holders"`
+}
@@ -0,0 +1,183 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/stringcache"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+	"go.uber.org/zap"
+)
+
+const (
+	aggregatedSupplyCacheDuration = 10 * time.Minute
+	aggregatedSupplyCachePrefix   = "api-server/chain-supply"
+
+	// maxSupplyPages bounds the number of sdk-service calls made to aggregate
+	// the supply of a single chain.
+	maxSupplyPages = 100
+)
+
+// fetchAllSupply walks every page of the chain supply returned by sdk-service.
+func fetchAllSupply(ctx context.Context, client sdkutilities.Service, chainName string) ([]Coin, error) {
+	var (
+		coins []Coin
+		key   *string
+	)
+
+	for page := 0; page < maxSupplyPages; page++ {
+		res, err := client.Supply(ctx, &sdkutilities.SupplyPayload{
+			ChainName:     chainName,
+			PaginationKey: key,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve supply page %d: %w", page, err)
+		}
+
+		for _, c := range res.Coins {
+			coins = append(coins, Coin{
+				Denom:  c.Denom,
+				Amount: c.Amount,
+			})
+		}
+
+		if res.Pagination == nil || res.Pagination.NextKey == nil || *res.Pagination.NextKey == "" {
+			return coins, nil
+		}
+
+		key = res.Pagination.NextKey
+	}
+
+	return nil, fmt.Errorf("supply of chain %s spans more than %d pages", chainName, maxSupplyPages)
+}
+
+// traceFunc resolves the origin chain of a denom trace, as
+// traceResolver.resolveDenomTrace does.
+type traceFunc func(trace tracelistener.IBCDenomTraceRow) (VerifiedTrace, *cns.Chain, error)
+
+// enrichSupply resolves IBC denoms of coins through traces and adds CNS
+// metadata to every coin.
+// Native denoms are looked up in the CNS denoms of chain, IBC denoms in the
+// ones of the chain they originate from, resolved by resolve.
+func enrichSupply(coins []Coin, chain cns.Chain, traces []tracelistener.IBCDenomTraceRow, resolve traceFunc) ([]SupplyCoin, error) {
+	tracesByHash := make(map[string]tracelistener.IBCDenomTraceRow, len(traces))
+	for _, t := range traces {
+		tracesByHash[strings.ToLower(t.Hash)] = t
+	}
+
+	ret := make([]SupplyCoin, 0, len(coins))
+	for _, c := range coins {
+		sc := SupplyCoin{
+			Denom:     c.Denom,
+			Amount:    c.Amount,
+			BaseDenom: c.Denom,
+		}
+
+		denoms := chain.Denoms
+		if strings.HasPrefix(c.Denom, "ibc/") {
+			denoms = nil
+
+			if trace, ok := tracesByHash[strings.ToLower(strings.TrimPrefix(c.Denom, "ibc/"))]; ok {
+				sc.BaseDenom = trace.BaseDenom
+				sc.Path = trace.Path
+
+				verifiedTrace, origin, err := resolve(trace)
+				if err != nil {
+					return nil, fmt.Errorf("cannot resolve trace of %s: %w", c.Denom, err)
+				}
+
+				sc.Verified = verifiedTrace.Verified
+				if origin != nil {
+					denoms = origin.Denoms
+				}
+			}
+		}
+
+		for _, d := range denoms {
+			if d.Name != sc.BaseDenom {
+				continue
+			}
+
+			if !strings.HasPrefix(c.Denom, "ibc/") {
+				sc.Verified = d.Verified
+			}
+
+			sc.DisplayName = d.DisplayName
+			sc.Ticker = d.Ticker
+			sc.Precision = d.Precision
+			sc.DisplayAmount = displayAmount(c.Amount, d.Precision)
+
+			break
+		}
+
+		ret = append(ret, sc)
+	}
+
+	return ret, nil
+}
+
+// displayAmount converts amount, expressed in the base unit, to the display
+// unit given its precision. An empty string is returned if amount is invalid.
+func displayAmount(amount string, precision int64) string {
+	amt, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return ""
+	}
+
+	if precision <= 0 {
+		return amt.String()
+	}
+
+	return sdktypes.NewDecFromBigIntWithPrec(amt, precision).String()
+}
+
+// aggregatedSupply walks the whole supply of chain and enriches each denom.
+func aggregatedSupply(ctx context.Context, logger *zap.SugaredLogger, db *database.Database, client sdkutilities.Service, chain cns.Chain) (AggregatedSupplyResponse, error) {
+	coins, err := fetchAllSupply(ctx, client, chain.ChainName)
+	if err != nil {
+		return AggregatedSupplyResponse{}, err
+	}
+
+	traces, err := db.DenomTraces(ctx, chain.ChainName)
+	if err != nil {
+		return AggregatedSupplyResponse{}, fmt.Errorf("cannot retrieve denom traces: %w", err)
+	}
+
+	resolver := newTraceResolver(logger, db)
+	supply, err := enrichSupply(coins, chain, traces, func(trace tracelistener.IBCDenomTraceRow) (VerifiedTrace, *cns.Chain, error) {
+		return resolver.resolveDenomTrace(ctx, chain.ChainName, trace)
+	})
+	if err != nil {
+		return AggregatedSupplyResponse{}, err
+	}
+
+	return AggregatedSupplyResponse{
+		Supply: supply,
+	}, nil
+}
+
+// aggregatedSupplyHandler returns a stringcache handler computing the JSON
+// encoded aggregated supply of chain.
+func aggregatedSupplyHandler(logger *zap.SugaredLogger, db *database.Database, client sdkutilities.Service, chain cns.Chain) stringcache.HandlerFunc {
+	return func(ctx context.Context, _ string) (string, error) {
+		res, err := aggregatedSupply(ctx, logger, db, client, chain)
+		if err != nil {
+			return "", err
+		}
+
+		bz, err := json.Marshal(res)
+		if err != nil {
+			return "", err
+		}
+
+		return string(bz), nil
+	}
+}
@@ -0,0 +1,131 @@
+package chains
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    string
+		precision int64
+		expected  string
+	}{
+		{"no precision", "1000", 0, "1000"},
+		{"six decimals", "1234567", 6, "1.234567000000000000"},
+		{"amount larger than int64", "123456789012345678901234", 6, "123456789012345678.901234000000000000"},
+		{"invalid amount", "abc", 6, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, displayAmount(tt.amount, tt.precision))
+		})
+	}
+}
+
+func TestEnrichSupply(t *testing.T) {
+	coins := []Coin{
+		{Denom: "uatom", Amount: "1000000"},
+		{Denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", Amount: "2000000"},
+		{Denom: "ibc/UNKNOWN", Amount: "3"},
+		{Denom: "ibc/C4CFF46FD6DE35CA4CF4CE031E643C8FDC9BA4B99AE598E9B0ED98FE3A2319F9", Amount: "4"},
+		{Denom: "uosmo", Amount: "5"},
+	}
+	traces := []tracelistener.IBCDenomTraceRow{
+		{
+			Path:      "transfer/channel-0",
+			BaseDenom: "uosmo",
+			Hash:      "27394fb092d2eccd56123c74f36e4c1f926001ceada9ca97ea622b25f41e5eb2",
+		},
+		{
+			Path:      "transfer/channel-1",
+			BaseDenom: "uatom",
+			Hash:      "c4cff46fd6de35ca4cf4ce031e643c8fdc9ba4b99ae598e9b0ed98fe3a2319f9",
+		},
+	}
+	chain := cns.Chain{
+		ChainName: "cosmos-hub",
+		Denoms: cns.DenomList{
+			{Name: "uatom", DisplayName: "ATOM", Ticker: "ATOM", Precision: 6, Verified: true},
+		},
+	}
+	osmosis := cns.Chain{
+		ChainName: "osmosis",
+		Denoms: cns.DenomList{
+			{Name: "uosmo", DisplayName: "OSMO", Ticker: "OSMO", Precision: 6, Verified: true},
+		},
+	}
+	// a chain declaring its own, unverified, uatom
+	fake := cns.Chain{
+		ChainName: "fake",
+		Denoms: cns.DenomList{
+			{Name: "uatom", DisplayName: "fake ATOM", Verified: false},
+		},
+	}
+
+	resolve := func(trace tracelistener.IBCDenomTraceRow) (VerifiedTrace, *cns.Chain, error) {
+		origin := map[string]cns.Chain{
+			"transfer/channel-0": osmosis,
+			"transfer/channel-1": fake,
+		}[trace.Path]
+
+		for _, d := range origin.Denoms {
+			if d.Name == trace.BaseDenom {
+				return VerifiedTrace{Verified: d.Verified}, &origin, nil
+			}
+		}
+
+		return VerifiedTrace{}, &origin, nil
+	}
+
+	res, err := enrichSupply(coins, chain, traces, resolve)
+	require.NoError(t, err)
+	require.Len(t, res, 5)
+
+	require.Equal(t, "uatom", res[0].BaseDenom)
+	require.True(t, res[0].Verified)
+	require.Equal(t, "1.000000000000000000", res[0].DisplayAmount)
+
+	require.Equal(t, "uosmo", res[1].BaseDenom)
+	require.Equal(t, "transfer/channel-0", res[1].Path)
+	require.Equal(t, "OSMO", res[1].DisplayName)
+	require.True(t, res[1].Verified)
+
+	require.Equal(t, "ibc/UNKNOWN", res[2].BaseDenom)
+	require.False(t, res[2].Verified)
+	require.Empty(t, res[2].DisplayAmount)
+
+	// verified on the queried chain, but not on the chain it comes from
+	require.Equal(t, "uatom", res[3].BaseDenom)
+	require.Equal(t, "fake ATOM", res[3].DisplayName)
+	require.False(t, res[3].Verified)
+
+	// verified on another chain, but not declared on the queried chain
+	require.Equal(t, "uosmo", res[4].BaseDenom)
+	require.False(t, res[4].Verified)
+	require.Empty(t, res[4].DisplayName)
+}
+
+func TestEnrichSupply_ResolveError(t *testing.T) {
+	coins := []Coin{
+		{Denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", Amount: "2000000"},
+	}
+	traces := []tracelistener.IBCDenomTraceRow{
+		{
+			Path:      "transfer/channel-0",
+			BaseDenom: "uosmo",
+			Hash:      "27394fb092d2eccd56123c74f36e4c1f926001ceada9ca97ea622b25f41e5eb2",
+		},
+	}
+
+	_, err := enrichSupply(coins, cns.Chain{}, traces, func(tracelistener.IBCDenomTraceRow) (VerifiedTrace, *cns.Chain, error) {
+		return VerifiedTrace{}, nil, errors.New("database down")
+	})
+	require.Error(t, err)
+}
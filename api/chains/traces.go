@@ -0,0 +1,356 @@
+package chains
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+)
+
+// traceResolver resolves IBC denom traces, caching the chain IDs, channel
+// and chain lookups so that many traces can be resolved at once without
+// repeating them.
+type traceResolver struct {
+	logger *zap.SugaredLogger
+	db     *database.Database
+
+	chainIDs   map[string]string
+	channels   map[channelKey]channelLookup
+	chains     map[string]chainLookup
+	lastBlocks map[string]tracelistener.BlockTimeRow
+}
+
+type channelKey struct {
+	chainName string
+	channel   string
+	chainID   string
+}
+
+type channelLookup struct {
+	info cns.IbcChannelsInfo
+	err  error
+}
+
+type chainLookup struct {
+	chain cns.Chain
+	err   error
+}
+
+func newTraceResolver(logger *zap.SugaredLogger, db *database.Database) *traceResolver {
+	return &traceResolver{
+		logger:     logger,
+		db:         db,
+		channels:   map[channelKey]channelLookup{},
+		chains:     map[string]chainLookup{},
+		lastBlocks: map[string]tracelistener.BlockTimeRow{},
+	}
+}
+
+// resolveTrace walks the IBC path of the denom identified by hash on
+// chainName up to the chain the denom originates from.
+// Verification failures are reported through the Verified and Cause fields
+// of the returned trace, while the returned error is only set on internal
+// failures.
+// The origin chain is returned whenever the path could be fully resolved.
+func resolveTrace(ctx context.Context, logger *zap.SugaredLogger, db *database.Database, chainName, hash string) (VerifiedTrace, *cns.Chain, error) {
+	return newTraceResolver(logger, db).resolve(ctx, chainName, hash)
+}
+
+func (r *traceResolver) chainIDsMap(ctx context.Context) (map[string]string, error) {
+	if r.chainIDs != nil {
+		return r.chainIDs, nil
+	}
+
+	chainIDs, err := r.db.ChainIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.chainIDs = chainIDs
+	return chainIDs, nil
+}
+
+func (r *traceResolver) channelToChain(ctx context.Context, chainName, channel, chainID string) (cns.IbcChannelsInfo, error) {
+	key := channelKey{chainName: chainName, channel: channel, chainID: chainID}
+	if l, ok := r.channels[key]; ok {
+		return l.info, l.err
+	}
+
+	info, err := r.db.GetIbcChannelToChain(ctx, chainName, channel, chainID)
+	r.channels[key] = channelLookup{info: info, err: err}
+
+	return info, err
+}
+
+func (r *traceResolver) chain(ctx context.Context, chainName string) (cns.Chain, error) {
+	if l, ok := r.chains[chainName]; ok {
+		return l.chain, l.err
+	}
+
+	chain, err := r.db.Chain(ctx, chainName)
+	r.chains[chainName] = chainLookup{chain: chain, err: err}
+
+	return chain, err
+}
+
+func (r *traceResolver) chainLastBlock(ctx context.Context, chainName string) (tracelistener.BlockTimeRow, error) {
+	if b, ok := r.lastBlocks[chainName]; ok {
+		return b, nil
+	}
+
+	b, err := r.db.ChainLastBlock(ctx, chainName)
+	if err != nil {
+		return tracelistener.BlockTimeRow{}, err
+	}
+
+	r.lastBlocks[chainName] = b
+	return b, nil
+}
+
+// resolve is the caching counterpart of resolveTrace.
+func (r *traceResolver) resolve(ctx context.Context, chainName, hash string) (VerifiedTrace, *cns.Chain, error) {
+	res := VerifiedTrace{
+		IbcDenom: IBCDenomHash(hash),
+	}
+
+	denomTrace, err := r.db.DenomTrace(ctx, chainName, hash)
+	if err != nil {
+		cause := fmt.Sprintf("token hash %v not found on chain %v", hash, chainName)
+
+		r.logger.Errorw(
+			cause,
+			"hash", hash,
+			"chainName", chainName,
+		)
+
+		res.Verified = false
+		res.Cause = cause
+
+		return res, nil, nil
+	}
+
+	return r.resolveDenomTrace(ctx, chainName, denomTrace)
+}
+
+// resolveDenomTrace walks the IBC path of denomTrace, known on chainName, as
+// resolve does.
+func (r *traceResolver) resolveDenomTrace(ctx context.Context, chainName string, denomTrace tracelistener.IBCDenomTraceRow) (VerifiedTrace, *cns.Chain, error) {
+	hash := denomTrace.Hash
+	res := VerifiedTrace{
+		IbcDenom:  IBCDenomHash(hash),
+		Path:      denomTrace.Path,
+		BaseDenom: denomTrace.BaseDenom,
+	}
+
+	pathsElements, err := paths(res.Path)
+	if err != nil {
+		cause := fmt.Sprintf("unsupported path %s", res.Path)
+
+		r.logger.Errorw(
+			"invalid denom",
+			"hash", hash,
+			"path", res.Path,
+			"err", cause,
+		)
+
+		res.Verified = false
+		res.Cause = cause
+
+		return res, nil, nil
+	}
+
+	chainIDsMap, err := r.chainIDsMap(ctx)
+	if err != nil {
+		err = fmt.Errorf("cannot query list of chain ids, %w", err)
+
+		e := apierrors.New(
+			"denom/verify-trace",
+			fmt.Sprintf("cannot query list of chain ids"),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot query list of chain ids: %w", err),
+			"hash",
+			hash,
+			"path",
+			res.Path,
+		)
+
+		return VerifiedTrace{}, nil, e
+	}
+
+	nextChain := chainName
+	for _, element := range pathsElements {
+		// every path element must be made of an ICS-20 port and a channel
+		port, channel, ok := splitPathElement(element)
+		if !ok {
+			cause := fmt.Sprintf("Unsupported path %s", res.Path)
+
+			r.logger.Errorw(
+				"invalid denom",
+				"hash", hash,
+				"path", res.Path,
+				"err", cause,
+			)
+
+			res.Verified = false
+			res.Cause = cause
+
+			return res, nil, nil
+		}
+
+		var channelInfo cns.IbcChannelsInfo
+		var trace Trace
+
+		chainID, ok := chainIDsMap[nextChain]
+		if !ok {
+			r.logger.Errorw(
+				"cannot check path element during path resolution",
+				"hash", hash,
+				"path", res.Path,
+				"err", fmt.Errorf("cannot find %s in chainIDs map", nextChain),
+			)
+
+			res.Verified = false
+			res.Cause = "cannot check path element during path resolution"
+
+			return res, nil, nil
+		}
+
+		channelInfo, err = r.channelToChain(ctx, nextChain, channel, chainID)
+		if err != nil {
+			if errors.As(err, &database.ErrNoDestChain{}) {
+				r.logger.Errorw(
+					err.Error(),
+					"hash", hash,
+					"path", res.Path,
+					"chain", chainName,
+				)
+
+				res.Verified = false
+				res.Cause = err.Error()
+
+				return res, nil, nil
+			}
+
+			e := apierrors.New(
+				"denom/verify-trace",
+				fmt.Sprintf("failed querying for %s, error: %v", hash, err),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("invalid number of query responses: %w", err),
+				"hash",
+				hash,
+			)
+
+			return VerifiedTrace{}, nil, e
+		}
+
+		trace.ChainName = channelInfo[0].ChainAName
+		trace.CounterpartyName = channelInfo[0].ChainBName
+		trace.Channel = channelInfo[0].ChainAChannelID
+		trace.Port = port
+
+		res.Trace = append(res.Trace, trace)
+
+		nextChain = trace.CounterpartyName
+	}
+
+	nextChainData, err := r.chain(ctx, nextChain)
+	if err != nil {
+		r.logger.Errorw(
+			"cannot query chain",
+			"hash", hash,
+			"path", res.Path,
+			"nextChain", nextChain,
+			"err", err,
+		)
+
+		// we did not find any chain with name nextChain
+		if errors.Is(err, sql.ErrNoRows) {
+			res.Verified = false
+			res.Cause = fmt.Sprintf("no chain with name %s found", nextChain)
+
+			return res, nil, nil
+		}
+
+		e := apierrors.New(
+			"denom/verify-trace",
+			fmt.Sprintf("database error, %v", err),
+			http.StatusInternalServerError,
+		).WithLogContext(
+			fmt.Errorf("cannot query chain with name: %w", err),
+			"hash",
+			hash,
+			"path",
+			res.Path,
+			"chain",
+			chainName,
+			"nextChain",
+			nextChain,
+		)
+
+		return VerifiedTrace{}, nil, e
+	}
+
+	cbt, err := r.chainLastBlock(ctx, nextChain)
+	if err != nil {
+		e := apierrors.New(
+			"denom/verify-trace",
+			fmt.Sprintf("cannot retrieve chain status for %v", nextChain),
+			http.StatusInternalServerError,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain last block time: %w", err),
+			"hash",
+			hash,
+			"path",
+			res.Path,
+			"chainName",
+			chainName,
+			"nextChain",
+			nextChain,
+		)
+
+		return VerifiedTrace{}, nil, e
+	}
+
+	r.logger.Debugw("last block time", "chain", nextChain, "time", cbt, "threshold_for_chain", nextChainData.ValidBlockThresh.Duration())
+
+	if time.Since(cbt.BlockTime) > nextChainData.ValidBlockThresh.Duration() {
+		res.Verified = false
+		res.Cause = fmt.Sprintf("chain %s status offline", nextChain)
+
+		return res, &nextChainData, nil
+	}
+
+	res.Verified = false
+
+	// set verifiedStatus for base denom on nextChain
+	for _, d := range nextChainData.Denoms {
+		if denomTrace.BaseDenom == d.Name {
+			res.Verified = d.Verified
+			break
+		}
+	}
+
+	return res, &nextChainData, nil
+}
+
+// splitPathElement splits a "port/channel" path element in its parts.
+func splitPathElement(element string) (string, string, bool) {
+	port, channel, ok := strings.Cut(element, "/")
+	if !ok || port == "" || channel == "" || strings.Contains(channel, "/") {
+		return "", "", false
+	}
+
+	return port, channel, true
+}
@@ -0,0 +1,32 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPathElement(t *testing.T) {
+	tests := []struct {
+		element string
+		port    string
+		channel string
+		ok      bool
+	}{
+		{"transfer/channel-0", "transfer", "channel-0", true},
+		{"wasm.osmo1contract/channel-42", "wasm.osmo1contract", "channel-42", true},
+		{"transfer", "", "", false},
+		{"/channel-0", "", "", false},
+		{"transfer/", "", "", false},
+		{"transfer/channel-0/uatom", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.element, func(t *testing.T) {
+			port, channel, ok := splitPathElement(tt.element)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.port, port)
+			require.Equal(t, tt.channel, channel)
+		})
+	}
+}
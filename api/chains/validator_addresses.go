@@ -0,0 +1,134 @@
+package chains
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+)
+
+// validatorAddressKind is the kind of address a bech32 string represents
+// with respect to a chain's bech32 configuration.
+type validatorAddressKind int
+
+const (
+	unknownAddress validatorAddressKind = iota
+	operatorAddress
+	consensusAddress
+	accountAddress
+)
+
+// consensusPubKey decodes the consensus public key stored by tracelistener.
+// The value can either be the raw key bytes or the protobuf-encoded key
+// message, depending on how it was stored.
+func consensusPubKey(keyType string, value []byte) (cryptotypes.PubKey, error) {
+	switch t := strings.ToLower(keyType); {
+	case strings.Contains(t, "ed25519"):
+		pk := &ed25519.PubKey{}
+		if len(value) == ed25519.PubKeySize {
+			pk.Key = value
+			return pk, nil
+		}
+
+		if err := pk.Unmarshal(value); err != nil {
+			return nil, fmt.Errorf("cannot decode ed25519 consensus key: %w", err)
+		}
+
+		return pk, nil
+	case strings.Contains(t, "secp256k1"):
+		pk := &secp256k1.PubKey{}
+		if len(value) == secp256k1.PubKeySize {
+			pk.Key = value
+			return pk, nil
+		}
+
+		if err := pk.Unmarshal(value); err != nil {
+			return nil, fmt.Errorf("cannot decode secp256k1 consensus key: %w", err)
+		}
+
+		return pk, nil
+	default:
+		return nil, fmt.Errorf("unsupported consensus key type %s", keyType)
+	}
+}
+
+// validatorConsensusAddress returns the bech32 consensus address (valcons) of v.
+func validatorConsensusAddress(cfg cns.Bech32Config, v tracelistener.ValidatorRow) (string, error) {
+	pk, err := consensusPubKey(v.ConsensusPubKeyType, v.ConsensusPubKeyValue)
+	if err != nil {
+		return "", err
+	}
+
+	return bech32.ConvertAndEncode(cfg.Bech32PrefixConsAddr(), pk.Address())
+}
+
+// validatorAccountAddress returns the account address the validator uses for
+// self-delegations, which shares its bytes with the operator address.
+func validatorAccountAddress(cfg cns.Bech32Config, v tracelistener.ValidatorRow) (string, error) {
+	hrp, bz, err := bech32.DecodeAndConvert(v.OperatorAddress)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode operator address: %w", err)
+	}
+
+	if hrp != cfg.Bech32PrefixValAddr() {
+		return "", fmt.Errorf("operator address prefix %s does not match chain prefix %s", hrp, cfg.Bech32PrefixValAddr())
+	}
+
+	return bech32.ConvertAndEncode(cfg.Bech32PrefixAccAddr(), bz)
+}
+
+// addressKind decodes address and returns which kind of validator-related
+// address it is for the given bech32 configuration.
+func addressKind(cfg cns.Bech32Config, address string) (validatorAddressKind, error) {
+	hrp, _, err := bech32.DecodeAndConvert(address)
+	if err != nil {
+		return unknownAddress, fmt.Errorf("cannot decode address: %w", err)
+	}
+
+	switch hrp {
+	case cfg.Bech32PrefixValAddr():
+		return operatorAddress, nil
+	case cfg.Bech32PrefixConsAddr():
+		return consensusAddress, nil
+	case cfg.Bech32PrefixAccAddr():
+		return accountAddress, nil
+	default:
+		return unknownAddress, fmt.Errorf("address prefix %s does not belong to this chain", hrp)
+	}
+}
+
+// findValidator returns the validator among validators which is identified by
+// address, whether it's an operator, consensus or account address.
+func findValidator(cfg cns.Bech32Config, validators []tracelistener.ValidatorRow, address string) (tracelistener.ValidatorRow, bool, error) {
+	kind, err := addressKind(cfg, address)
+	if err != nil {
+		return tracelistener.ValidatorRow{}, false, err
+	}
+
+	for _, v := range validators {
+		var candidate string
+		switch kind {
+		case operatorAddress:
+			candidate = v.OperatorAddress
+		case consensusAddress:
+			candidate, err = validatorConsensusAddress(cfg, v)
+		case accountAddress:
+			candidate, err = validatorAccountAddress(cfg, v)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if candidate == address {
+			return v, true, nil
+		}
+	}
+
+	return tracelistener.ValidatorRow{}, false, nil
+}
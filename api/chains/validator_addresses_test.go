@@ -0,0 +1,105 @@
+package chains
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+var testBech32Config = cns.Bech32Config{
+	MainPrefix:      "cosmos",
+	PrefixAccount:   "acc",
+	PrefixValidator: "val",
+	PrefixConsensus: "cons",
+	PrefixPublic:    "pub",
+	PrefixOperator:  "oper",
+}
+
+func testValidator(t *testing.T) (tracelistener.ValidatorRow, string, string) {
+	t.Helper()
+
+	key := make([]byte, ed25519.PubKeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	operatorBytes := make([]byte, 20)
+	for i := range operatorBytes {
+		operatorBytes[i] = byte(100 + i)
+	}
+
+	operator, err := bech32.ConvertAndEncode("cosmosvaloper", operatorBytes)
+	require.NoError(t, err)
+	account, err := bech32.ConvertAndEncode("cosmos", operatorBytes)
+	require.NoError(t, err)
+	keyHash := sha256.Sum256(key)
+	consensus, err := bech32.ConvertAndEncode("cosmosvalcons", keyHash[:20])
+	require.NoError(t, err)
+
+	return tracelistener.ValidatorRow{
+		OperatorAddress:      operator,
+		ConsensusPubKeyType:  "/cosmos.crypto.ed25519.PubKey",
+		ConsensusPubKeyValue: key,
+	}, consensus, account
+}
+
+func TestValidatorDerivedAddresses(t *testing.T) {
+	v, expConsensus, expAccount := testValidator(t)
+
+	consensus, err := validatorConsensusAddress(testBech32Config, v)
+	require.NoError(t, err)
+	require.Equal(t, expConsensus, consensus)
+
+	account, err := validatorAccountAddress(testBech32Config, v)
+	require.NoError(t, err)
+	require.Equal(t, expAccount, account)
+
+	// protobuf-encoded keys resolve to the same address
+	pk := ed25519.PubKey{Key: v.ConsensusPubKeyValue}
+	encoded, err := pk.Marshal()
+	require.NoError(t, err)
+	v.ConsensusPubKeyValue = encoded
+
+	consensus, err = validatorConsensusAddress(testBech32Config, v)
+	require.NoError(t, err)
+	require.Equal(t, expConsensus, consensus)
+}
+
+func TestFindValidator(t *testing.T) {
+	v, consensus, account := testValidator(t)
+	other := tracelistener.ValidatorRow{
+		OperatorAddress:     "cosmosvaloper1invalid",
+		ConsensusPubKeyType: "unknown",
+	}
+	validators := []tracelistener.ValidatorRow{other, v}
+
+	tests := []struct {
+		name    string
+		address string
+		found   bool
+		wantErr bool
+	}{
+		{"operator address", v.OperatorAddress, true, false},
+		{"consensus address", consensus, true, false},
+		{"account address", account, true, false},
+		{"address of another chain", "osmo1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu", false, true},
+		{"malformed address", "notanaddress", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, found, err := findValidator(testBech32Config, validators, tt.address)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.found, found)
+			require.Equal(t, v, res)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package chains
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/emerishq/demeris-api-server/api/database"
@@ -11,7 +13,9 @@ import (
 	"github.com/emerishq/demeris-api-server/lib/ginutils"
 	"github.com/emerishq/demeris-api-server/lib/keybase"
 	"github.com/emerishq/demeris-api-server/lib/stringcache"
+	"github.com/emerishq/demeris-backend-models/cns"
 	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
 	"github.com/emerishq/emeris-utils/logging"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -41,10 +45,10 @@ func GetValidators(db *database.Database, cache stringcache.CacheBackend) gin.Ha
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
 		var res ValidatorsResponse
 
-		chainName := c.Param("chain")
-		validators, err := db.GetValidators(ctx, chainName)
+		validators, err := db.GetValidators(ctx, chain.ChainName)
 		if err != nil {
 			e := apierrors.New(
 				"validators",
@@ -53,7 +57,7 @@ func GetValidators(db *database.Database, cache stringcache.CacheBackend) gin.Ha
 			).WithLogContext(
 				fmt.Errorf("cannot retrieve validators: %w", err),
 				"chain",
-				chainName,
+				chain.ChainName,
 			)
 			_ = c.Error(e)
 
@@ -61,19 +65,14 @@ func GetValidators(db *database.Database, cache stringcache.CacheBackend) gin.Ha
 		}
 
 		adaptValidators := make([]*Validator, 0, len(validators))
-		avatarCache := stringcache.NewStringCache(
-			logger,
-			cache,
-			avatarCacheDuration,
-			avatarCachePrefix,
-			stringcache.HandlerFunc(fetchKeybaseAvatar),
-		)
+		avatarCache := newAvatarCache(logger, cache)
 		for _, v := range validators {
-			adapted, err := adaptValidator(c.Request.Context(), avatarCache, v)
+			adapted, err := adaptValidator(c.Request.Context(), avatarCache, chain.NodeInfo.Bech32Config, v)
 			if err != nil {
 				logger.Warnw(
-					"cannot get avatar for validator",
+					"cannot adapt validator",
 					"validatorIdentity", v.Identity,
+					"operatorAddress", v.OperatorAddress,
 					"error", err,
 				)
 			}
@@ -87,15 +86,132 @@ func GetValidators(db *database.Database, cache stringcache.CacheBackend) gin.Ha
 	}
 }
 
-func adaptValidator(ctx context.Context, cache *stringcache.StringCache, r tracelistener.ValidatorRow) (*Validator, error) {
+// GetValidator returns a validator looked up by any of its addresses.
+// @Summary Gets a validator by operator, consensus or account address.
+// @Tags Chain
+// @ID validator
+// @Description Gets a validator of a chain by its operator (valoper), consensus (valcons)
+// @Description or self-delegation account address.
+// @Param chainName path string true "chain name"
+// @Param address path string true "operator, consensus or account address"
+// @Produce json
+// @Success 200 {object} ValidatorResponse
+// @Failure 500,400,404 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/validator/{address} [get]
+func GetValidator(db *database.Database, cache stringcache.CacheBackend) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		chain := ginutils.GetValue[cns.Chain](c, ChainContextKey)
+
+		address := c.Param("address")
+
+		validators, err := db.GetValidators(ctx, chain.ChainName)
+		if err != nil {
+			e := apierrors.New(
+				"validators",
+				fmt.Sprintf("cannot retrieve validators"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve validators: %w", err),
+				"chain",
+				chain.ChainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		v, found, err := findValidator(chain.NodeInfo.Bech32Config, validators, address)
+		if err != nil {
+			e := apierrors.New(
+				"validators",
+				fmt.Sprintf("invalid address %v", address),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot resolve validator address: %w", err),
+				"chain",
+				chain.ChainName,
+				"address",
+				address,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		if !found {
+			e := apierrors.New(
+				"validators",
+				fmt.Sprintf("validator %v not found", address),
+				http.StatusNotFound,
+			).WithLogContext(
+				fmt.Errorf("no validator matches address"),
+				"chain",
+				chain.ChainName,
+				"address",
+				address,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		adapted, err := adaptValidator(ctx, newAvatarCache(logger, cache), chain.NodeInfo.Bech32Config, v)
+		if err != nil {
+			logger.Warnw(
+				"cannot adapt validator",
+				"validatorIdentity", v.Identity,
+				"operatorAddress", v.OperatorAddress,
+				"error", err,
+			)
+		}
+
+		c.JSON(http.StatusOK, ValidatorResponse{Validator: adapted})
+	}
+}
+
+func newAvatarCache(logger *zap.SugaredLogger, cache stringcache.CacheBackend) *stringcache.StringCache {
+	return stringcache.NewStringCache(
+		logger,
+		cache,
+		avatarCacheDuration,
+		avatarCachePrefix,
+		stringcache.HandlerFunc(fetchKeybaseAvatar),
+	)
+}
+
+// adaptValidator converts r into a Validator, filling its avatar and derived
+// addresses. The returned Validator is always usable, even if err is not nil.
+func adaptValidator(ctx context.Context, cache *stringcache.StringCache, cfg cns.Bech32Config, r tracelistener.ValidatorRow) (*Validator, error) {
 	var v = &Validator{ValidatorRow: r}
-	var err error
+	var errs []string
 
 	if len(r.Identity) > 0 {
-		v.Avatar, err = cache.Get(ctx, r.Identity, true)
+		avatar, err := cache.Get(ctx, r.Identity, true)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot get avatar: %v", err))
+		}
+		v.Avatar = avatar
+	}
+
+	consAddr, err := validatorConsensusAddress(cfg, r)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("cannot derive consensus address: %v", err))
+	}
+	v.ConsensusAddress = consAddr
+
+	accAddr, err := validatorAccountAddress(cfg, r)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("cannot derive account address: %v", err))
+	}
+	v.AccountAddress = accAddr
+
+	if len(errs) > 0 {
+		return v, errors.New(strings.Join(errs, "; "))
 	}
 
-	return v, err
+	return v, nil
 }
 
 func fetchKeybaseAvatar(ctx context.Context, key string) (string, error) {
@@ -107,3 +223,156 @@ func fetchKeybaseAvatar(ctx context.Context, key string) (string, error) {
 
 	return avatar, err
 }
+
+// GetValidatorHistory returns the timeline of changes of a validator.
+// @Summary Gets the change history of a validator.
+// @Tags Chain
+// @ID validator-history
+// @Description Gets the commission changes, jail and unjail events, status transitions
+// @Description and token drops which look like slashing of a validator.
+// @Param chainName path string true "chain name"
+// @Param operator path string true "validator operator address"
+// @Produce json
+// @Success 200 {object} ValidatorHistoryResponse
+// @Failure 500,400,404 {object} apierrors.UserFacingError
+// @Router /chain/{chainName}/validators/{operator}/history [get]
+func GetValidatorHistory(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		chainName := c.Param("chain")
+		operator := c.Param("operator")
+
+		rows, err := db.ValidatorHistory(ctx, chainName, operator)
+		if err != nil {
+			e := apierrors.New(
+				"validators",
+				fmt.Sprintf("cannot retrieve history for validator %v", operator),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve validator history: %w", err),
+				"chain",
+				chainName,
+				"operator",
+				operator,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		if len(rows) == 0 {
+			e := apierrors.New(
+				"validators",
+				fmt.Sprintf("validator %v not found", operator),
+				http.StatusNotFound,
+			).WithLogContext(
+				fmt.Errorf("no validator rows found"),
+				"chain",
+				chainName,
+				"operator",
+				operator,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, validatorHistory(operator, rows))
+	}
+}
+
+// validatorHistory walks rows, which must be sorted by height, and builds the
+// list of events found between each row and the previous one.
+func validatorHistory(operator string, rows []tracelistener.ValidatorRow) ValidatorHistoryResponse {
+	res := ValidatorHistoryResponse{
+		OperatorAddress: operator,
+		Events:          []ValidatorEvent{},
+	}
+
+	for i := 1; i < len(rows); i++ {
+		prev, cur := rows[i-1], rows[i]
+		event := ValidatorEvent{
+			Height:     cur.Height,
+			UpdateTime: cur.UpdateTime,
+		}
+
+		if prev.CommissionRate != cur.CommissionRate {
+			event.Type = ValidatorEventCommissionChange
+			event.From = prev.CommissionRate
+			event.To = cur.CommissionRate
+			res.Events = append(res.Events, event)
+
+			if decGT(cur.CommissionRate, prev.CommissionRate) {
+				res.CommissionIncreased = true
+			}
+		}
+
+		if prev.Jailed != cur.Jailed {
+			event.Type = ValidatorEventUnjailed
+			if cur.Jailed {
+				event.Type = ValidatorEventJailed
+				res.Jailed = true
+			}
+			event.From, event.To = "", ""
+			res.Events = append(res.Events, event)
+		}
+
+		if prev.Status != cur.Status {
+			event.Type = ValidatorEventStatusChange
+			event.From = bondStatus(prev.Status)
+			event.To = bondStatus(cur.Status)
+			res.Events = append(res.Events, event)
+		}
+
+		// Slashing burns bonded tokens without touching the delegator shares,
+		// while undelegations reduce both.
+		if decGT(prev.Tokens, cur.Tokens) && decEqual(prev.DelegatorShares, cur.DelegatorShares) {
+			event.Type = ValidatorEventPossibleSlash
+			event.From = prev.Tokens
+			event.To = cur.Tokens
+			res.Events = append(res.Events, event)
+		}
+	}
+
+	if len(rows) > 0 && rows[len(rows)-1].Jailed {
+		res.Jailed = true
+	}
+
+	return res
+}
+
+func bondStatus(status int32) string {
+	switch status {
+	case 1:
+		return "BOND_STATUS_UNBONDED"
+	case 2:
+		return "BOND_STATUS_UNBONDING"
+	case 3:
+		return "BOND_STATUS_BONDED"
+	default:
+		return "BOND_STATUS_UNSPECIFIED"
+	}
+}
+
+// decGT returns true if a > b, false if any of them is not a valid decimal.
+func decGT(a, b string) bool {
+	x, errA := sdktypes.NewDecFromStr(a)
+	y, errB := sdktypes.NewDecFromStr(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return x.GT(y)
+}
+
+// decEqual returns true if a == b, false if any of them is not a valid decimal.
+func decEqual(a, b string) bool {
+	x, errA := sdktypes.NewDecFromStr(a)
+	y, errB := sdktypes.NewDecFromStr(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return x.Equal(y)
+}
@@ -0,0 +1,103 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+func validatorRow(height uint64, commission string, jailed bool, status int32, tokens, shares string) tracelistener.ValidatorRow {
+	return tracelistener.ValidatorRow{
+		TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{
+			Height: height,
+		},
+		OperatorAddress: "cosmosvaloper1",
+		CommissionRate:  commission,
+		Jailed:          jailed,
+		Status:          status,
+		Tokens:          tokens,
+		DelegatorShares: shares,
+	}
+}
+
+func TestValidatorHistory(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []tracelistener.ValidatorRow
+		want ValidatorHistoryResponse
+	}{
+		{
+			"single row has no events",
+			[]tracelistener.ValidatorRow{
+				validatorRow(1, "0.05", false, 3, "100", "100.0"),
+			},
+			ValidatorHistoryResponse{
+				OperatorAddress: "cosmosvaloper1",
+				Events:          []ValidatorEvent{},
+			},
+		},
+		{
+			"commission increase",
+			[]tracelistener.ValidatorRow{
+				validatorRow(1, "0.05", false, 3, "100", "100.0"),
+				validatorRow(2, "0.10", false, 3, "100", "100.0"),
+			},
+			ValidatorHistoryResponse{
+				OperatorAddress: "cosmosvaloper1",
+				Events: []ValidatorEvent{
+					{Type: ValidatorEventCommissionChange, Height: 2, From: "0.05", To: "0.10"},
+				},
+				CommissionIncreased: true,
+			},
+		},
+		{
+			"commission decrease",
+			[]tracelistener.ValidatorRow{
+				validatorRow(1, "0.10", false, 3, "100", "100.0"),
+				validatorRow(2, "0.05", false, 3, "100", "100.0"),
+			},
+			ValidatorHistoryResponse{
+				OperatorAddress: "cosmosvaloper1",
+				Events: []ValidatorEvent{
+					{Type: ValidatorEventCommissionChange, Height: 2, From: "0.10", To: "0.05"},
+				},
+			},
+		},
+		{
+			"jailed with slash then unjailed",
+			[]tracelistener.ValidatorRow{
+				validatorRow(1, "0.05", false, 3, "100", "100.0"),
+				validatorRow(2, "0.05", true, 2, "95", "100.0"),
+				validatorRow(3, "0.05", false, 3, "95", "100.0"),
+			},
+			ValidatorHistoryResponse{
+				OperatorAddress: "cosmosvaloper1",
+				Events: []ValidatorEvent{
+					{Type: ValidatorEventJailed, Height: 2},
+					{Type: ValidatorEventStatusChange, Height: 2, From: "BOND_STATUS_BONDED", To: "BOND_STATUS_UNBONDING"},
+					{Type: ValidatorEventPossibleSlash, Height: 2, From: "100", To: "95"},
+					{Type: ValidatorEventUnjailed, Height: 3},
+					{Type: ValidatorEventStatusChange, Height: 3, From: "BOND_STATUS_UNBONDING", To: "BOND_STATUS_BONDED"},
+				},
+				Jailed: true,
+			},
+		},
+		{
+			"undelegation is not a slash",
+			[]tracelistener.ValidatorRow{
+				validatorRow(1, "0.05", false, 3, "100", "100.0"),
+				validatorRow(2, "0.05", false, 3, "90", "90.0"),
+			},
+			ValidatorHistoryResponse{
+				OperatorAddress: "cosmosvaloper1",
+				Events:          []ValidatorEvent{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, validatorHistory("cosmosvaloper1", tt.rows))
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
 	"github.com/emerishq/emeris-utils/validation"
 
 	"github.com/emerishq/emeris-utils/configuration"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/emerishq/demeris-api-server/lib/ratelimit"
 )
 
 type Config struct {
@@ -20,6 +27,47 @@ type Config struct {
 	SentryTracesSampleRate float64
 	FeatureFlags           []string
 
+	// IBCTimeoutMargin is how long IBC transfers are given to reach their
+	// destination chain before timing out.
+	IBCTimeoutMargin time.Duration
+
+	// TendermintRPCEndpoints overrides the Tendermint RPC URL of chains,
+	// with "chain=URL" entries. Other chains are reached at
+	// TendermintRPCURLFormat, formatted with their name, which defaults to
+	// the in-cluster node of the chain.
+	TendermintRPCEndpoints []string
+	TendermintRPCURLFormat string
+
+	// GasAdjustment multiplies the gas used by simulated txs to compute the
+	// gas limit fee estimates are quoted for.
+	GasAdjustment float64
+
+	// PriceOracleURL is the base URL of the price oracle fee estimates are
+	// converted to fiat with. Fiat values are left out if it's not set.
+	PriceOracleURL string
+
+	// SequenceReservationTTL is how long a sequence reserved for an account
+	// is kept from others when no tx is relayed with it.
+	SequenceReservationTTL time.Duration
+
+	// TxRateLimit and TxRateBurst limit the txs a client broadcasts on a
+	// chain, to TxRateLimit per second with bursts of TxRateBurst.
+	// SimulateRateLimit and SimulateRateBurst limit tx simulations the same
+	// way. A zero rate lifts the limit.
+	TxRateLimit       float64
+	TxRateBurst       int
+	SimulateRateLimit float64
+	SimulateRateBurst int
+
+	// RateLimitAllowlist holds the IPs and CIDRs of clients which aren't
+	// rate limited, such as internal services.
+	RateLimitAllowlist []string
+
+	// TrustedProxies holds the IPs and CIDRs of the proxies whose
+	// X-Forwarded-For and X-Real-IP headers are trusted to tell the IP of
+	// clients. Other clients are identified by their peer address.
+	TrustedProxies []string
+
 	Debug bool
 }
 
@@ -29,9 +77,48 @@ func (c Config) Validate() error {
 		return validation.MissingFieldsErr(err, false)
 	}
 
+	if _, err := ratelimit.ParseAllowlist(c.RateLimitAllowlist); err != nil {
+		return fmt.Errorf("invalid rate limit allowlist, %w", err)
+	}
+
+	for _, p := range c.TrustedProxies {
+		if net.ParseIP(p) != nil {
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(p); err != nil {
+			return fmt.Errorf("invalid trusted proxy %s, %w", p, err)
+		}
+	}
+
+	if _, err := c.TendermintRPCEndpointsByChain(); err != nil {
+		return fmt.Errorf("invalid Tendermint RPC endpoints, %w", err)
+	}
+
 	return nil
 }
 
+// TendermintRPCEndpointsByChain returns the TendermintRPCEndpoints URLs
+// indexed by chain name.
+func (c Config) TendermintRPCEndpointsByChain() (map[string]string, error) {
+	ret := make(map[string]string, len(c.TendermintRPCEndpoints))
+	for _, e := range c.TendermintRPCEndpoints {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+
+		chainName, url, ok := strings.Cut(e, "=")
+		if !ok || chainName == "" || url == "" {
+			return nil, fmt.Errorf("invalid endpoint %s, expected chain=URL", e)
+		}
+
+		ret[chainName] = url
+	}
+
+	return ret, nil
+}
+
 func Read() (*Config, error) {
 	var c Config
 
@@ -42,5 +129,12 @@ func Read() (*Config, error) {
 		"SentryEnvironment":      "notset",
 		"SentrySampleRate":       "1.0",
 		"SentryTracesSampleRate": "0.01",
+		"IBCTimeoutMargin":       "10m",
+		"GasAdjustment":          "1.3",
+		"SequenceReservationTTL": "2m",
+		"TxRateLimit":            "1",
+		"TxRateBurst":            "10",
+		"SimulateRateLimit":      "5",
+		"SimulateRateBurst":      "20",
 	})
 }
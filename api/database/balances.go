@@ -5,6 +5,7 @@ import (
 
 	"github.com/emerishq/demeris-backend-models/tracelistener"
 	"github.com/getsentry/sentry-go"
+	"github.com/lib/pq"
 )
 
 func (d *Database) Balances(ctx context.Context, address string) ([]tracelistener.BalanceRow, error) {
@@ -33,3 +34,121 @@ func (d *Database) Balances(ctx context.Context, address string) ([]tracelistene
 
 	return balances, d.dbi.DB.SelectContext(ctx, &balances, q, address)
 }
+
+// BalancesByAddresses returns the balances of every address of addresses
+// on the enabled chains.
+func (d *Database) BalancesByAddresses(ctx context.Context, addresses []string) ([]tracelistener.BalanceRow, error) {
+	defer sentry.StartSpan(ctx, "db.BalancesByAddresses").Finish()
+
+	var balances []tracelistener.BalanceRow
+
+	q := `
+		SELECT
+		id,
+		chain_name,
+		height,
+		delete_height,
+		address,
+		amount,
+		denom
+		FROM tracelistener.balances
+		WHERE address=ANY(?)
+		AND chain_name IN (
+			SELECT chain_name FROM cns.chains WHERE enabled=true
+		)
+		AND delete_height IS NULL
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return balances, d.dbi.DB.SelectContext(ctx, &balances, q, pq.Array(addresses))
+}
+
+// DenomTotal is the sum of the non-zero balances of a denom on a chain,
+// held by Address if set.
+type DenomTotal struct {
+	ChainName string `db:"chain_name"`
+	Address   string `db:"address"`
+	Denom     string `db:"denom"`
+	Amount    string `db:"amount"`
+	Holders   int    `db:"holders"`
+}
+
+// balanceAmount is the amount of tracelistener.balances rows as a decimal,
+// since it may or may not be suffixed by the denom.
+const balanceAmount = `(
+	CASE WHEN right(amount, length(denom)) = denom
+	THEN left(amount, length(amount) - length(denom))
+	ELSE amount END
+)::DECIMAL`
+
+// DenomTotals returns the totals of the denoms of denoms on each enabled
+// chain.
+func (d *Database) DenomTotals(ctx context.Context, denoms []string) ([]DenomTotal, error) {
+	defer sentry.StartSpan(ctx, "db.DenomTotals").Finish()
+
+	var totals []DenomTotal
+
+	q := `
+		SELECT
+		chain_name,
+		denom,
+		SUM(amount)::TEXT AS amount,
+		COUNT(*) AS holders
+		FROM (
+			SELECT
+			chain_name,
+			denom,
+			` + balanceAmount + ` AS amount
+			FROM tracelistener.balances
+			WHERE denom=ANY(?)
+			AND chain_name IN (
+				SELECT chain_name FROM cns.chains WHERE enabled=true
+			)
+			AND delete_height IS NULL
+		) AS b
+		WHERE amount > 0
+		GROUP BY chain_name, denom
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return totals, d.dbi.DB.SelectContext(ctx, &totals, q, pq.Array(denoms))
+}
+
+// DenomTotalsByAddresses returns the totals of the denoms of denoms held by
+// each address of addresses on each enabled chain.
+func (d *Database) DenomTotalsByAddresses(ctx context.Context, denoms []string, addresses []string) ([]DenomTotal, error) {
+	defer sentry.StartSpan(ctx, "db.DenomTotalsByAddresses").Finish()
+
+	var totals []DenomTotal
+
+	q := `
+		SELECT
+		chain_name,
+		address,
+		denom,
+		SUM(amount)::TEXT AS amount,
+		COUNT(*) AS holders
+		FROM (
+			SELECT
+			chain_name,
+			address,
+			denom,
+			` + balanceAmount + ` AS amount
+			FROM tracelistener.balances
+			WHERE denom=ANY(?)
+			AND address=ANY(?)
+			AND chain_name IN (
+				SELECT chain_name FROM cns.chains WHERE enabled=true
+			)
+			AND delete_height IS NULL
+		) AS b
+		WHERE amount > 0
+		GROUP BY chain_name, address, denom
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return totals, d.dbi.DB.SelectContext(ctx, &totals, q, pq.Array(denoms), pq.Array(addresses))
+}
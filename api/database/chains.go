@@ -156,6 +156,7 @@ func (d *Database) ChainLastBlock(ctx context.Context, name string) (tracelisten
 	SELECT
 		id,
 		chain_name,
+		height,
 		block_time
 	FROM tracelistener.blocktime 
 	WHERE 
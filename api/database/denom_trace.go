@@ -39,3 +39,91 @@ func (d *Database) DenomTrace(ctx context.Context, chain string, hash string) (t
 
 	return denomTrace, nil
 }
+
+// DenomTraces returns all the denom traces known for a given chain.
+func (d *Database) DenomTraces(ctx context.Context, chain string) ([]tracelistener.IBCDenomTraceRow, error) {
+	defer sentry.StartSpan(ctx, "db.DenomTraces").Finish()
+
+	var denomTraces []tracelistener.IBCDenomTraceRow
+
+	q := `
+	SELECT
+	id,
+	chain_name,
+	height,
+	delete_height,
+	path,
+	base_denom,
+	hash
+	FROM tracelistener.denom_traces
+	WHERE chain_name=?
+	AND base_denom != ''
+	AND delete_height IS NULL
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return denomTraces, d.dbi.DB.SelectContext(ctx, &denomTraces, q, chain)
+}
+
+// DenomTracesByHash returns the denom traces matching hash on every enabled
+// chain. Hash param is case-insensitive.
+func (d *Database) DenomTracesByHash(ctx context.Context, hash string) ([]tracelistener.IBCDenomTraceRow, error) {
+	defer sentry.StartSpan(ctx, "db.DenomTracesByHash").Finish()
+
+	var denomTraces []tracelistener.IBCDenomTraceRow
+
+	// note: lower() since Tracelistener stores hashes in lowercase
+	q := `
+	SELECT
+	id,
+	chain_name,
+	height,
+	delete_height,
+	path,
+	base_denom,
+	hash
+	FROM tracelistener.denom_traces
+	WHERE hash=lower(?)
+	AND base_denom != ''
+	AND delete_height IS NULL
+	AND chain_name IN (
+		SELECT chain_name FROM cns.chains WHERE enabled=true
+	)
+	ORDER BY chain_name
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return denomTraces, d.dbi.DB.SelectContext(ctx, &denomTraces, q, hash)
+}
+
+// DenomTracesByBaseDenom returns the denom traces of baseDenom on every
+// enabled chain.
+func (d *Database) DenomTracesByBaseDenom(ctx context.Context, baseDenom string) ([]tracelistener.IBCDenomTraceRow, error) {
+	defer sentry.StartSpan(ctx, "db.DenomTracesByBaseDenom").Finish()
+
+	var denomTraces []tracelistener.IBCDenomTraceRow
+
+	q := `
+	SELECT
+	id,
+	chain_name,
+	height,
+	delete_height,
+	path,
+	base_denom,
+	hash
+	FROM tracelistener.denom_traces
+	WHERE base_denom=?
+	AND delete_height IS NULL
+	AND chain_name IN (
+		SELECT chain_name FROM cns.chains WHERE enabled=true
+	)
+	ORDER BY chain_name
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return denomTraces, d.dbi.DB.SelectContext(ctx, &denomTraces, q, baseDenom)
+}
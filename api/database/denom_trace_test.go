@@ -2,6 +2,7 @@ package database_test
 
 import (
 	"context"
+	"strings"
 
 	utils "github.com/emerishq/demeris-api-server/api/test_utils"
 )
@@ -60,3 +61,93 @@ func (s *TestSuite) TestDenomTrace() {
 		})
 	}
 }
+
+func (s *TestSuite) TestDenomTraces() {
+	tests := []struct {
+		name      string
+		chainName string
+		expLen    int
+	}{
+		{
+			"chain not found",
+			"invalidChain",
+			0,
+		},
+		{
+			"inserted chain with denom traces",
+			utils.ChainWithoutPublicEndpoints.ChainName,
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			res, err := s.ctx.Router.DB.DenomTraces(context.Background(), tt.chainName)
+			s.Require().NoError(err)
+			s.Require().Len(res, tt.expLen)
+			for _, r := range res {
+				s.Require().Equal(tt.chainName, r.ChainName)
+			}
+		})
+	}
+}
+
+func (s *TestSuite) TestDenomTracesByHash() {
+	tests := []struct {
+		name   string
+		hash   string
+		expLen int
+	}{
+		{
+			"unknown hash",
+			"invalidhash",
+			0,
+		},
+		{
+			"known hash, case insensitive",
+			strings.ToUpper(utils.VerifyTraceData.Denoms[0].Hash),
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			res, err := s.ctx.Router.DB.DenomTracesByHash(context.Background(), tt.hash)
+			s.Require().NoError(err)
+			s.Require().Len(res, tt.expLen)
+			for _, r := range res {
+				s.Require().Equal(strings.ToLower(tt.hash), r.Hash)
+			}
+		})
+	}
+}
+
+func (s *TestSuite) TestDenomTracesByBaseDenom() {
+	tests := []struct {
+		name      string
+		baseDenom string
+		expLen    int
+	}{
+		{
+			"unknown base denom",
+			"invalid",
+			0,
+		},
+		{
+			"known base denom",
+			utils.VerifyTraceData.Denoms[0].BaseDenom,
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			res, err := s.ctx.Router.DB.DenomTracesByBaseDenom(context.Background(), tt.baseDenom)
+			s.Require().NoError(err)
+			s.Require().Len(res, tt.expLen)
+			for _, r := range res {
+				s.Require().Equal(tt.baseDenom, r.BaseDenom)
+			}
+		})
+	}
+}
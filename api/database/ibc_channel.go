@@ -77,3 +77,103 @@ func (d *Database) GetIbcChannelToChain(ctx context.Context, chain, channel, cha
 
 	return c, nil
 }
+
+// IBCChannelEdge is a channel of an enabled chain, along with the connection
+// and client it's built on and the chain at the other end of it.
+// Connection, client and counterparty fields are nil when they can't be
+// resolved.
+type IBCChannelEdge struct {
+	ChainName             string  `db:"chain_name"`
+	ChainID               string  `db:"chain_id"`
+	ChannelID             string  `db:"channel_id"`
+	CounterChannelID      string  `db:"counter_channel_id"`
+	Port                  string  `db:"port"`
+	State                 int32   `db:"state"`
+	ConnectionID          *string `db:"connection_id"`
+	ClientID              *string `db:"client_id"`
+	CounterpartyChainID   *string `db:"counterparty_chain_id"`
+	CounterpartyChainName *string `db:"counterparty_chain_name"`
+}
+
+// IBCChannelEdges returns every channel of the enabled chains, resolving the
+// counterparty chain the same way GetIbcChannelToChain does.
+func (d *Database) IBCChannelEdges(ctx context.Context) ([]IBCChannelEdge, error) {
+	defer sentry.StartSpan(ctx, "db.IBCChannelEdges").Finish()
+
+	return d.ibcChannelEdges(ctx, "")
+}
+
+// ChainIBCChannelEdges returns the channels of chain, resolving their
+// counterparty chain the same way GetIbcChannelToChain does.
+func (d *Database) ChainIBCChannelEdges(ctx context.Context, chain string) ([]IBCChannelEdge, error) {
+	defer sentry.StartSpan(ctx, "db.ChainIBCChannelEdges").Finish()
+
+	return d.ibcChannelEdges(ctx, chain)
+}
+
+// ibcChannelEdges returns the channel edges of chain, or of every enabled
+// chain if chain is empty.
+func (d *Database) ibcChannelEdges(ctx context.Context, chain string) ([]IBCChannelEdge, error) {
+	var edges []IBCChannelEdge
+
+	subQ := `SELECT
+		tracelistener.channels.chain_name,
+		tracelistener.channels.channel_id,
+		tracelistener.channels.counter_channel_id,
+		tracelistener.channels.port,
+		tracelistener.channels.state,
+		tracelistener.connections.connection_id,
+		tracelistener.clients.client_id,
+		tracelistener.clients.chain_id
+	FROM
+		tracelistener.channels
+		LEFT JOIN tracelistener.connections ON
+				tracelistener.channels.hops[1]
+				= tracelistener.connections.connection_id
+			AND
+				tracelistener.connections.chain_name
+				= tracelistener.channels.chain_name
+		LEFT JOIN tracelistener.clients ON
+				tracelistener.clients.client_id
+				= tracelistener.connections.client_id
+			AND
+			tracelistener.clients.chain_name
+			= tracelistener.channels.chain_name
+	WHERE tracelistener.channels.delete_height IS NULL`
+
+	q := `
+		SELECT
+			c1.chain_name AS chain_name,
+			cc.node_info->>'chain_id' AS chain_id,
+			c1.channel_id AS channel_id,
+			c1.counter_channel_id AS counter_channel_id,
+			c1.port AS port,
+			c1.state AS state,
+			c1.connection_id AS connection_id,
+			c1.client_id AS client_id,
+			c1.chain_id AS counterparty_chain_id,
+			c2.chain_name AS counterparty_chain_name
+		FROM
+			(
+				` + subQ + `
+			) c1
+				INNER JOIN
+			cns.chains cc
+			ON cc.chain_name = c1.chain_name
+			AND cc.enabled = TRUE
+				LEFT JOIN
+			(
+				` + subQ + `
+			) c2
+			ON c1.channel_id = c2.counter_channel_id
+			AND c1.counter_channel_id = c2.channel_id
+			AND c1.chain_name != c2.chain_name
+			AND c2.chain_id = cc.node_info->>'chain_id'
+		WHERE ? = '' OR c1.chain_name = ?
+		ORDER BY c1.chain_name, c1.channel_id
+		`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return edges, d.dbi.DB.SelectContext(ctx, &edges, q, chain, chain)
+}
@@ -66,3 +66,30 @@ func (s *TestSuite) TestGetIbcChannelToChain() {
 		})
 	}
 }
+
+func (s *TestSuite) TestIBCChannelEdges() {
+	res, err := s.ctx.Router.DB.IBCChannelEdges(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(res, len(utils.VerifyTraceData.Channels))
+
+	for _, e := range res {
+		s.Require().NotNil(e.CounterpartyChainName)
+		s.Require().NotEqual(e.ChainName, *e.CounterpartyChainName)
+		s.Require().NotNil(e.ConnectionID)
+		s.Require().NotNil(e.ClientID)
+	}
+
+	s.Require().Equal(utils.VerifyTraceData.Channels[0].ChainName, res[0].ChainName)
+	s.Require().Equal(utils.VerifyTraceData.Channels[0].ChannelID, res[0].ChannelID)
+	s.Require().Equal(utils.VerifyTraceData.Channels[1].ChainName, *res[0].CounterpartyChainName)
+}
+
+func (s *TestSuite) TestChainIBCChannelEdges() {
+	res, err := s.ctx.Router.DB.ChainIBCChannelEdges(context.Background(), utils.ChainWithoutPublicEndpoints.ChainName)
+	s.Require().NoError(err)
+	s.Require().Len(res, 1)
+	s.Require().Equal(utils.VerifyTraceData.Channels[0].ChannelID, res[0].ChannelID)
+	s.Require().Equal(utils.VerifyTraceData.Channels[0].Port, res[0].Port)
+	s.Require().NotNil(res[0].CounterpartyChainName)
+	s.Require().Equal(utils.VerifyTraceData.Channels[1].ChainName, *res[0].CounterpartyChainName)
+}
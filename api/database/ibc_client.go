@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
 	"github.com/getsentry/sentry-go"
 	"github.com/lib/pq"
 )
@@ -76,3 +77,30 @@ func (d *Database) QueryIBCClientTrace(ctx context.Context, chain string, channe
 
 	return clients, nil
 }
+
+// Clients returns all the IBC clients of chain.
+func (d *Database) Clients(ctx context.Context, chain string) ([]tracelistener.IBCClientStateRow, error) {
+	defer sentry.StartSpan(ctx, "db.Clients").Finish()
+
+	var clients []tracelistener.IBCClientStateRow
+
+	q := `
+	SELECT
+	id,
+	chain_name,
+	height,
+	delete_height,
+	chain_id,
+	client_id,
+	latest_height,
+	trusting_period
+	FROM tracelistener.clients
+	WHERE chain_name=?
+	AND delete_height IS NULL
+	ORDER BY client_id
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return clients, d.dbi.DB.SelectContext(ctx, &clients, q, chain)
+}
@@ -50,3 +50,16 @@ func (s *TestSuite) TestQueryIBCClientTrace() {
 		})
 	}
 }
+
+func (s *TestSuite) TestClients() {
+	res, err := s.ctx.Router.DB.Clients(context.Background(), utils.ChainWithoutPublicEndpoints.ChainName)
+	s.Require().NoError(err)
+	s.Require().Len(res, 1)
+	s.Require().Equal(utils.VerifyTraceData.Clients[0].ClientID, res[0].ClientID)
+	s.Require().Equal(utils.VerifyTraceData.Clients[0].DestChainID, res[0].ChainID)
+	s.Require().Equal(uint64(99), res[0].LatestHeight)
+
+	res, err = s.ctx.Router.DB.Clients(context.Background(), "invalidChain")
+	s.Require().NoError(err)
+	s.Require().Empty(res)
+}
@@ -38,3 +38,44 @@ func (d *Database) Connection(ctx context.Context, chain string, connection_id s
 
 	return connection, nil
 }
+
+// IBCConnection is a connection along with the chain ID tracked by its
+// client, that is the chain ID of its counterparty.
+type IBCConnection struct {
+	tracelistener.IBCConnectionRow
+
+	CounterpartyChainID *string `db:"counterparty_chain_id"`
+}
+
+// Connections returns all the connections of chain.
+func (d *Database) Connections(ctx context.Context, chain string) ([]IBCConnection, error) {
+	defer sentry.StartSpan(ctx, "db.Connections").Finish()
+
+	var connections []IBCConnection
+
+	q := `
+	SELECT
+	conn.id,
+	conn.chain_name,
+	conn.height,
+	conn.delete_height,
+	conn.connection_id,
+	conn.client_id,
+	conn.state,
+	conn.counter_connection_id,
+	conn.counter_client_id,
+	cl.chain_id AS counterparty_chain_id
+	FROM tracelistener.connections conn
+	LEFT JOIN tracelistener.clients cl
+	ON cl.client_id = conn.client_id
+	AND cl.chain_name = conn.chain_name
+	AND cl.delete_height IS NULL
+	WHERE conn.chain_name=?
+	AND conn.delete_height IS NULL
+	ORDER BY conn.connection_id
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return connections, d.dbi.DB.SelectContext(ctx, &connections, q, chain)
+}
@@ -55,3 +55,17 @@ func (s *TestSuite) TestConnection() {
 		})
 	}
 }
+
+func (s *TestSuite) TestConnections() {
+	res, err := s.ctx.Router.DB.Connections(context.Background(), utils.ChainWithoutPublicEndpoints.ChainName)
+	s.Require().NoError(err)
+	s.Require().Len(res, 1)
+	s.Require().Equal(utils.VerifyTraceData.Connections[0].ConnectionID, res[0].ConnectionID)
+	s.Require().Equal(utils.VerifyTraceData.Connections[0].ClientID, res[0].ClientID)
+	s.Require().NotNil(res[0].CounterpartyChainID)
+	s.Require().Equal(utils.VerifyTraceData.Clients[0].DestChainID, *res[0].CounterpartyChainID)
+
+	res, err = s.ctx.Router.DB.Connections(context.Background(), "invalidChain")
+	s.Require().NoError(err)
+	s.Require().Empty(res)
+}
@@ -0,0 +1,41 @@
+package database
+
+import "github.com/emerishq/emeris-utils/database"
+
+// The api-server only owns the apiserver database, the others are migrated by
+// the services writing to them.
+
+const createDatabase = `
+CREATE DATABASE IF NOT EXISTS apiserver;
+`
+
+const createTableTxJournal = `
+CREATE TABLE IF NOT EXISTS apiserver.tx_journal (
+	id serial unique primary key,
+	chain_name text not null,
+	tx_hash text not null,
+	owner text not null,
+	signer text not null,
+	msg_types text[] not null,
+	submitted_at timestamp not null default now(),
+	status text not null,
+	height int,
+	code int,
+	error text,
+	updated_at timestamp,
+	unique(chain_name, tx_hash),
+	index(owner, id),
+	index(signer, id)
+)
+`
+
+var migrationList = []string{
+	createDatabase,
+	createTableTxJournal,
+}
+
+// RunMigrations creates the api-server database and tables if they don't
+// exist yet. It's only run by the server, which needs DDL rights for it.
+func (d *Database) RunMigrations() error {
+	return database.RunMigrations(d.connectionURL, migrationList)
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/lib/pq"
+)
+
+// TxJournalEntry is a transaction relayed by the api-server.
+type TxJournalEntry struct {
+	ID          int64          `db:"id" json:"-"`
+	ChainName   string         `db:"chain_name" json:"chain_name"`
+	TxHash      string         `db:"tx_hash" json:"tx_hash"`
+	Owner       string         `db:"owner" json:"owner"`
+	Signer      string         `db:"signer" json:"signer"`
+	MsgTypes    pq.StringArray `db:"msg_types" json:"msg_types" swaggertype:"array,string"`
+	SubmittedAt time.Time      `db:"submitted_at" json:"submitted_at"`
+	Status      string         `db:"status" json:"status"`
+	Height      *int64         `db:"height" json:"height,omitempty"`
+	Code        *int64         `db:"code" json:"code,omitempty"`
+	Error       *string        `db:"error" json:"error,omitempty"`
+	UpdatedAt   *time.Time     `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// TxJournalResult is the final result of a journaled transaction.
+type TxJournalResult struct {
+	Status string
+	Height *int64
+	Code   *int64
+	Error  *string
+}
+
+// TxJournalFilter restricts the transactions returned by TxJournal.
+// Zero fields don't filter.
+type TxJournalFilter struct {
+	ChainName string
+	Status    string
+	MsgType   string
+	From      time.Time
+	To        time.Time
+	// Before only returns transactions journaled before the one with ID
+	// Before, for pagination.
+	Before int64
+	Limit  int
+}
+
+// InsertTxJournal journals the transaction entry.
+// Transactions already journaled are left untouched.
+func (d *Database) InsertTxJournal(ctx context.Context, entry TxJournalEntry) error {
+	defer sentry.StartSpan(ctx, "db.InsertTxJournal").Finish()
+
+	q := `
+	INSERT INTO apiserver.tx_journal
+	(chain_name, tx_hash, owner, signer, msg_types, submitted_at, status)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (chain_name, tx_hash) DO NOTHING
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	_, err := d.dbi.DB.ExecContext(
+		ctx,
+		q,
+		entry.ChainName,
+		entry.TxHash,
+		entry.Owner,
+		entry.Signer,
+		entry.MsgTypes,
+		entry.SubmittedAt,
+		entry.Status,
+	)
+
+	return err
+}
+
+// SetTxJournalResult records the result of the journaled transaction txHash
+// on chainName.
+// Transactions already journaled with the status of res are left untouched.
+func (d *Database) SetTxJournalResult(ctx context.Context, chainName, txHash string, res TxJournalResult) error {
+	defer sentry.StartSpan(ctx, "db.SetTxJournalResult").Finish()
+
+	q := `
+	UPDATE apiserver.tx_journal
+	SET status=?, height=?, code=?, error=?, updated_at=now()
+	WHERE chain_name=?
+	AND tx_hash=?
+	AND status<>?
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	_, err := d.dbi.DB.ExecContext(ctx, q, res.Status, res.Height, res.Code, res.Error, chainName, txHash, res.Status)
+
+	return err
+}
+
+// TxJournal returns the transactions journaled for address, either as their
+// owner or their signer, most recent first.
+func (d *Database) TxJournal(ctx context.Context, address string, filter TxJournalFilter) ([]TxJournalEntry, error) {
+	defer sentry.StartSpan(ctx, "db.TxJournal").Finish()
+
+	conditions := []string{"(owner=? OR signer=?)"}
+	args := []interface{}{address, address}
+
+	if filter.ChainName != "" {
+		conditions = append(conditions, "chain_name=?")
+		args = append(args, filter.ChainName)
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status=?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.MsgType != "" {
+		conditions = append(conditions, "?=ANY(msg_types)")
+		args = append(args, filter.MsgType)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "submitted_at>=?")
+		args = append(args, filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "submitted_at<?")
+		args = append(args, filter.To)
+	}
+
+	if filter.Before != 0 {
+		conditions = append(conditions, "id<?")
+		args = append(args, filter.Before)
+	}
+
+	q := fmt.Sprintf(`
+	SELECT
+	id,
+	chain_name,
+	tx_hash,
+	owner,
+	signer,
+	msg_types,
+	submitted_at,
+	status,
+	height,
+	code,
+	error,
+	updated_at
+	FROM apiserver.tx_journal
+	WHERE %s
+	ORDER BY id DESC
+	LIMIT ?
+	`, strings.Join(conditions, "\n\tAND "))
+
+	args = append(args, filter.Limit)
+
+	q = d.dbi.DB.Rebind(q)
+
+	var entries []TxJournalEntry
+	if err := d.dbi.DB.SelectContext(ctx, &entries, q, args...); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,80 @@
+package database_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+)
+
+func (s *TestSuite) TestTxJournal() {
+	ctx := context.Background()
+	db := s.ctx.Router.DB
+	now := time.Now().UTC().Truncate(time.Second)
+
+	entries := []database.TxJournalEntry{
+		{ChainName: "cosmos-hub", TxHash: "AAAA", Owner: "owner", Signer: "cosmos1signer", MsgTypes: []string{"/cosmos.bank.v1beta1.MsgSend"}, SubmittedAt: now.Add(-2 * time.Hour), Status: "pending"},
+		{ChainName: "osmosis", TxHash: "BBBB", Owner: "owner", Signer: "osmo1signer", MsgTypes: []string{"/ibc.applications.transfer.v1.MsgTransfer"}, SubmittedAt: now.Add(-time.Hour), Status: "pending"},
+		{ChainName: "cosmos-hub", TxHash: "CCCC", Owner: "other", Signer: "cosmos1signer", MsgTypes: []string{"/cosmos.staking.v1beta1.MsgDelegate"}, SubmittedAt: now, Status: "pending"},
+	}
+
+	for _, e := range entries {
+		s.Require().NoError(db.InsertTxJournal(ctx, e))
+	}
+
+	// journaling a tx again is a no-op
+	s.Require().NoError(db.InsertTxJournal(ctx, entries[0]))
+
+	height, code := int64(42), int64(0)
+	s.Require().NoError(db.SetTxJournalResult(ctx, "cosmos-hub", "AAAA", database.TxJournalResult{
+		Status: "complete",
+		Height: &height,
+		Code:   &code,
+	}))
+
+	hashes := func(txs []database.TxJournalEntry) []string {
+		ret := []string{}
+		for _, tx := range txs {
+			ret = append(ret, tx.TxHash)
+		}
+		return ret
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		filter  database.TxJournalFilter
+		exp     []string
+	}{
+		{"by owner", "owner", database.TxJournalFilter{Limit: 10}, []string{"BBBB", "AAAA"}},
+		{"by signer", "cosmos1signer", database.TxJournalFilter{Limit: 10}, []string{"CCCC", "AAAA"}},
+		{"by chain", "owner", database.TxJournalFilter{ChainName: "osmosis", Limit: 10}, []string{"BBBB"}},
+		{"by status", "owner", database.TxJournalFilter{Status: "complete", Limit: 10}, []string{"AAAA"}},
+		{"by msg type", "cosmos1signer", database.TxJournalFilter{MsgType: "/cosmos.staking.v1beta1.MsgDelegate", Limit: 10}, []string{"CCCC"}},
+		{"by time", "owner", database.TxJournalFilter{From: now.Add(-90 * time.Minute), To: now, Limit: 10}, []string{"BBBB"}},
+		{"limited", "owner", database.TxJournalFilter{Limit: 1}, []string{"BBBB"}},
+		{"unknown address", "nobody", database.TxJournalFilter{Limit: 10}, []string{}},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			res, err := db.TxJournal(ctx, tt.address, tt.filter)
+			s.Require().NoError(err)
+			s.Require().Equal(tt.exp, hashes(res))
+		})
+	}
+
+	s.Run("paginated", func() {
+		page, err := db.TxJournal(ctx, "owner", database.TxJournalFilter{Limit: 1})
+		s.Require().NoError(err)
+		s.Require().Len(page, 1)
+
+		page, err = db.TxJournal(ctx, "owner", database.TxJournalFilter{Before: page[0].ID, Limit: 1})
+		s.Require().NoError(err)
+		s.Require().Equal([]string{"AAAA"}, hashes(page))
+		s.Require().Equal("complete", page[0].Status)
+		s.Require().Equal(height, *page[0].Height)
+		s.Require().Equal(code, *page[0].Code)
+		s.Require().Nil(page[0].Error)
+	})
+}
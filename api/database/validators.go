@@ -46,3 +46,46 @@ func (d *Database) GetValidators(ctx context.Context, chain string) ([]tracelist
 
 	return validators, d.dbi.DB.SelectContext(ctx, &validators, q, chain)
 }
+
+// ValidatorHistory returns every row tracelistener recorded for a validator,
+// including the ones which have been superseded, ordered by height.
+func (d *Database) ValidatorHistory(ctx context.Context, chain string, operator string) ([]tracelistener.ValidatorRow, error) {
+	defer sentry.StartSpan(ctx, "db.ValidatorHistory").Finish()
+
+	var validators []tracelistener.ValidatorRow
+
+	q := `
+	SELECT
+	id,
+	chain_name,
+	height,
+	delete_height,
+	operator_address,
+	consensus_pubkey_type,
+	consensus_pubkey_value,
+	jailed,
+	status,
+	tokens,
+	delegator_shares,
+	moniker,
+	identity,
+	website,
+	security_contact,
+	details,
+	unbonding_height,
+	unbonding_time,
+	commission_rate,
+	max_rate,
+	max_change_rate,
+	update_time,
+	min_self_delegation
+	FROM tracelistener.validators
+	WHERE chain_name=?
+	AND operator_address=?
+	ORDER BY height ASC, id ASC
+	`
+
+	q = d.dbi.DB.Rebind(q)
+
+	return validators, d.dbi.DB.SelectContext(ctx, &validators, q, chain, operator)
+}
@@ -0,0 +1,471 @@
+package ibc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+)
+
+func Register(router *gin.Engine, db *database.Database) {
+	router.Group("/ibc").
+		GET("/denom_hash", GetDenomHash(db)).
+		GET("/route", GetRoute(db)).
+		GET("/topology", GetTopology(db)).
+		GET("/primary_channels/audit", GetPrimaryChannelAudit(db)).
+		GET("/escrow", GetEscrow(db))
+}
+
+// GetDenomHash computes the IBC denom hash of a trace and looks it up on
+// every enabled chain.
+// @Summary Computes the IBC denom hash of a trace.
+// @Tags IBC
+// @ID ibc-denom-hash
+// @Description Computes the IBC denom hash of a trace, lists the enabled chains holding a denom trace for it,
+// @Description and the denom the token gets when it arrives on each chain through its primary channel.
+// @Description The trace is either given as a full denom (e.g. transfer/channel-0/uatom), or as path and base_denom.
+// @Param denom query string false "full denom trace, e.g. transfer/channel-0/uatom"
+// @Param path query string false "trace path, e.g. transfer/channel-0"
+// @Param base_denom query string false "base denom, e.g. uatom"
+// @Param chain query string false "chain currently holding the token, defaults to the chains holding its trace"
+// @Produce json
+// @Success 200 {object} DenomHashResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /ibc/denom_hash [get]
+func GetDenomHash(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		trace, err := traceFromQuery(c)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("invalid denom trace: %v", err),
+				http.StatusBadRequest,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		res := DenomHashResponse{
+			Path:      trace.Path,
+			BaseDenom: trace.BaseDenom,
+			Denom:     trace.IBCDenom(),
+			Chains:    []string{},
+		}
+
+		if trace.Path != "" {
+			res.Hash = trace.Hash()
+
+			traces, err := db.DenomTracesByHash(ctx, res.Hash)
+			if err != nil {
+				e := apierrors.New(
+					"ibc",
+					fmt.Sprintf("cannot retrieve denom traces"),
+					http.StatusInternalServerError,
+				).WithLogContext(
+					fmt.Errorf("cannot retrieve denom traces: %w", err),
+					"hash",
+					res.Hash,
+				)
+				_ = c.Error(e)
+
+				return
+			}
+
+			for _, t := range traces {
+				res.Chains = append(res.Chains, t.ChainName)
+			}
+		}
+
+		chains, err := db.Chains(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve chains"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chains: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		holders := res.Chains
+		switch {
+		case c.Query("chain") != "":
+			holders = []string{c.Query("chain")}
+		case trace.Path == "":
+			holders = nativeChains(chains, trace.BaseDenom)
+		}
+
+		res.PrimaryChannelDenoms = primaryChannelDenoms(chains, trace, holders)
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func traceFromQuery(c *gin.Context) (denomTrace, error) {
+	if denom := c.Query("denom"); denom != "" {
+		return parseDenomTrace(denom)
+	}
+
+	baseDenom := c.Query("base_denom")
+	if baseDenom == "" {
+		return denomTrace{}, fmt.Errorf("either denom or base_denom must be specified")
+	}
+
+	path := c.Query("path")
+	if path != "" {
+		parsed, err := parseDenomTrace(path + "/" + baseDenom)
+		if err != nil {
+			return denomTrace{}, err
+		}
+
+		if parsed.Path != path {
+			return denomTrace{}, fmt.Errorf("malformed path %s", path)
+		}
+	}
+
+	return denomTrace{
+		Path:      path,
+		BaseDenom: baseDenom,
+	}, nil
+}
+
+// GetRoute returns how to move a token between two chains.
+// @Summary Finds the IBC route to move a token between two chains.
+// @Tags IBC
+// @ID ibc-route
+// @Description Finds the IBC route to move a token between two chains. IBC vouchers are first unwound back to
+// @Description their origin chain following their trace path, then forwarded through primary channels.
+// @Param from query string true "chain currently holding the token"
+// @Param to query string true "destination chain"
+// @Param denom query string true "denom of the token on the from chain, either native or ibc/<hash>"
+// @Produce json
+// @Success 200 {object} RouteResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /ibc/route [get]
+func GetRoute(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		from, to, denom := c.Query("from"), c.Query("to"), c.Query("denom")
+		if from == "" || to == "" || denom == "" {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("from, to and denom must be specified"),
+				http.StatusBadRequest,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		trace := denomTrace{BaseDenom: denom}
+		if hash := strings.TrimPrefix(denom, "ibc/"); hash != denom {
+			dt, err := db.DenomTrace(ctx, from, hash)
+			if err != nil {
+				e := apierrors.New(
+					"ibc",
+					fmt.Sprintf("token hash %v not found on chain %v", hash, from),
+					http.StatusBadRequest,
+				).WithLogContext(
+					fmt.Errorf("cannot retrieve denom trace: %w", err),
+					"hash",
+					hash,
+					"chain",
+					from,
+				)
+				_ = c.Error(e)
+
+				return
+			}
+
+			trace = denomTrace{
+				Path:      dt.Path,
+				BaseDenom: dt.BaseDenom,
+			}
+		}
+
+		chains, err := db.Chains(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve chains"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chains: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		chainIDs, err := db.ChainIDs(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve chain ids"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chain ids: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		route, err := newRoutePlanner(chains, dbChannelResolver(db, chainIDs)).plan(ctx, from, to, trace)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot find route: %v", err),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot plan route: %w", err),
+				"from",
+				from,
+				"to",
+				to,
+				"denom",
+				denom,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, RouteResponse{Route: route})
+	}
+}
+
+// dbChannelResolver resolves channel ends through the tracelistener channels,
+// connections and clients.
+func dbChannelResolver(db *database.Database, chainIDs map[string]string) channelResolverFunc {
+	return func(ctx context.Context, chainName, channel string) (channelEnd, error) {
+		chainID, ok := chainIDs[chainName]
+		if !ok {
+			return channelEnd{}, fmt.Errorf("cannot find %s in chainIDs map", chainName)
+		}
+
+		info, err := db.GetIbcChannelToChain(ctx, chainName, channel, chainID)
+		if err != nil {
+			return channelEnd{}, err
+		}
+
+		return channelEnd{
+			ChainName: info[0].ChainBName,
+			Channel:   info[0].ChainBChannelID,
+		}, nil
+	}
+}
+
+// GetTopology returns the IBC graph of chains, channels, connections and
+// clients.
+// @Summary Gets the IBC topology.
+// @Tags IBC
+// @ID ibc-topology
+// @Description Gets the IBC graph of enabled chains, along with their channels, connections and clients.
+// @Description Channels are marked as CNS primary channels, or as unused when they're open but not primary.
+// @Description The graph is served as Graphviz DOT when text/vnd.graphviz is accepted.
+// @Produce json
+// @Produce text/vnd.graphviz
+// @Success 200 {object} TopologyResponse
+// @Failure 500 {object} apierrors.UserFacingError
+// @Router /ibc/topology [get]
+func GetTopology(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		chains, err := db.Chains(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve chains"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chains: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		edges, err := db.IBCChannelEdges(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve channels"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve channel edges: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		topology := buildTopology(chains, edges)
+
+		switch c.NegotiateFormat(gin.MIMEJSON, mimeGraphviz) {
+		case mimeGraphviz:
+			c.Data(http.StatusOK, mimeGraphviz+"; charset=utf-8", []byte(topology.dot()))
+		default:
+			c.JSON(http.StatusOK, TopologyResponse{Topology: topology})
+		}
+	}
+}
+
+// GetPrimaryChannelAudit audits the CNS primary channels against
+// tracelistener IBC data.
+// @Summary Audits CNS primary channels.
+// @Tags IBC
+// @ID ibc-primary-channels-audit
+// @Description Checks every CNS primary channel against tracelistener IBC data, reporting missing or closed channels,
+// @Description counterparty chain id mismatches, primary channels not pointing at each other and chains lacking a primary channel to an enabled peer.
+// @Produce json
+// @Success 200 {object} PrimaryChannelAuditResponse
+// @Failure 500 {object} apierrors.UserFacingError
+// @Router /ibc/primary_channels/audit [get]
+func GetPrimaryChannelAudit(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		audit, err := AuditPrimaryChannels(c.Request.Context(), db)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot audit primary channels"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot audit primary channels: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, PrimaryChannelAuditResponse{Audit: audit})
+	}
+}
+
+// GetEscrow reconciles the tokens escrowed on transfer channels with their
+// vouchers on the counterparty chains.
+// @Summary Gets IBC escrow accounting.
+// @Tags IBC
+// @ID ibc-escrow
+// @Description For every transfer channel, gets the balances of its ICS-20 escrow address and compares them
+// @Description with the total amount of the matching vouchers held on the counterparty chain.
+// @Param chain query string false "only report channels of this chain"
+// @Param channel query string false "only report this channel"
+// @Produce json
+// @Success 200 {object} EscrowResponse
+// @Failure 500 {object} apierrors.UserFacingError
+// @Router /ibc/escrow [get]
+func GetEscrow(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		chains, err := db.Chains(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve chains"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chains: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		edges, err := db.IBCChannelEdges(ctx)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve channels"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve channel edges: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		channels := escrowChannels(chains, edges, c.Query("chain"), c.Query("channel"))
+
+		addresses := make([]string, 0, len(channels))
+		for _, ch := range channels {
+			addresses = append(addresses, ch.hexAddress)
+		}
+
+		escrowBalances, err := db.BalancesByAddresses(ctx, addresses)
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve escrow balances"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve escrow balances: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		traces := map[string]map[string]tracelistener.IBCDenomTraceRow{}
+		for _, b := range escrowBalances {
+			if _, ok := traces[b.ChainName]; ok || !strings.HasPrefix(b.Denom, "ibc/") {
+				continue
+			}
+
+			chainTraces, err := db.DenomTraces(ctx, b.ChainName)
+			if err != nil {
+				e := apierrors.New(
+					"ibc",
+					fmt.Sprintf("cannot retrieve denom traces"),
+					http.StatusInternalServerError,
+				).WithLogContext(
+					fmt.Errorf("cannot retrieve denom traces: %w", err),
+					"chain",
+					b.ChainName,
+				)
+				_ = c.Error(e)
+
+				return
+			}
+
+			traces[b.ChainName] = make(map[string]tracelistener.IBCDenomTraceRow, len(chainTraces))
+			for _, t := range chainTraces {
+				traces[b.ChainName][strings.ToLower(t.Hash)] = t
+			}
+		}
+
+		voucherTotals, err := db.DenomTotals(ctx, voucherDenoms(channels, escrowBalances, traces))
+		if err != nil {
+			e := apierrors.New(
+				"ibc",
+				fmt.Sprintf("cannot retrieve voucher supply"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve voucher totals: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, EscrowResponse{
+			Channels: reconcileEscrows(channels, escrowBalances, voucherTotals, traces),
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package ibc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/cns"
+)
+
+// Kinds of primary channel issues.
+const (
+	// IssueMissingChannel is reported when a primary channel is unknown to
+	// tracelistener.
+	IssueMissingChannel = "missing_channel"
+	// IssueChannelNotOpen is reported when a primary channel isn't open.
+	IssueChannelNotOpen = "channel_not_open"
+	// IssueChainIDMismatch is reported when the client a primary channel is
+	// built on tracks another chain than its CNS counterparty.
+	IssueChainIDMismatch = "counterparty_chain_id_mismatch"
+	// IssueUnknownCounterparty is reported when a primary channel points to
+	// a chain that isn't enabled.
+	IssueUnknownCounterparty = "unknown_counterparty"
+	// IssueNotReciprocal is reported when the counterparty primary channel
+	// doesn't point back at the channel.
+	IssueNotReciprocal = "not_reciprocal"
+	// IssueMissingPrimaryChannel is reported when an enabled chain has an
+	// open transfer channel to another enabled chain, but no primary channel
+	// to it.
+	IssueMissingPrimaryChannel = "missing_primary_channel"
+)
+
+// AuditPrimaryChannels checks the CNS primary channels of every enabled
+// chain against tracelistener IBC data.
+func AuditPrimaryChannels(ctx context.Context, db *database.Database) (PrimaryChannelAudit, error) {
+	chains, err := db.Chains(ctx)
+	if err != nil {
+		return PrimaryChannelAudit{}, fmt.Errorf("cannot retrieve chains: %w", err)
+	}
+
+	edges, err := db.IBCChannelEdges(ctx)
+	if err != nil {
+		return PrimaryChannelAudit{}, fmt.Errorf("cannot retrieve channel edges: %w", err)
+	}
+
+	return auditPrimaryChannels(chains, edges), nil
+}
+
+func auditPrimaryChannels(chains []cns.Chain, edges []database.IBCChannelEdge) PrimaryChannelAudit {
+	byName := make(map[string]cns.Chain, len(chains))
+	byChainID := make(map[string]string, len(chains))
+	for _, c := range chains {
+		byName[c.ChainName] = c
+		byChainID[c.NodeInfo.ChainID] = c.ChainName
+	}
+
+	type edgeKey struct {
+		chainName string
+		channelID string
+	}
+
+	type peerKey struct {
+		chainName        string
+		counterpartyName string
+	}
+
+	edgesByKey := make(map[edgeKey]database.IBCChannelEdge, len(edges))
+	// pairs of chains linked by an open transfer channel
+	openPeers := make(map[peerKey]bool)
+	for _, e := range edges {
+		// non-transfer channels can't be primary channels
+		if e.Port != transferPort {
+			continue
+		}
+
+		edgesByKey[edgeKey{chainName: e.ChainName, channelID: e.ChannelID}] = e
+
+		if e.State != channelStateOpen {
+			continue
+		}
+
+		counterpartyName := apiutils.StringValue(e.CounterpartyChainName)
+		if counterpartyName == "" {
+			counterpartyName = byChainID[apiutils.StringValue(e.CounterpartyChainID)]
+		}
+
+		if counterpartyName != "" {
+			openPeers[peerKey{chainName: e.ChainName, counterpartyName: counterpartyName}] = true
+		}
+	}
+
+	ret := PrimaryChannelAudit{
+		Issues: []PrimaryChannelIssue{},
+	}
+
+	addIssue := func(kind, chainName, counterparty, channel, details string) {
+		ret.Issues = append(ret.Issues, PrimaryChannelIssue{
+			Kind:             kind,
+			ChainName:        chainName,
+			CounterpartyName: counterparty,
+			Channel:          channel,
+			Details:          details,
+		})
+	}
+
+	for _, chain := range chains {
+		for _, peer := range chains {
+			if peer.ChainName == chain.ChainName {
+				continue
+			}
+
+			// chains which can't reach each other need no primary channel
+			if !openPeers[peerKey{chainName: chain.ChainName, counterpartyName: peer.ChainName}] {
+				continue
+			}
+
+			if _, ok := chain.PrimaryChannel[peer.ChainName]; !ok {
+				addIssue(IssueMissingPrimaryChannel, chain.ChainName, peer.ChainName, "",
+					fmt.Sprintf("%s has an open transfer channel but no primary channel to %s", chain.ChainName, peer.ChainName))
+			}
+		}
+
+		for _, counterpartyName := range sortedKeys(chain.PrimaryChannel) {
+			channel := chain.PrimaryChannel[counterpartyName]
+			ret.Checked++
+
+			counterparty, enabled := byName[counterpartyName]
+			if !enabled {
+				addIssue(IssueUnknownCounterparty, chain.ChainName, counterpartyName, channel,
+					fmt.Sprintf("%s is not an enabled chain", counterpartyName))
+				continue
+			}
+
+			edge, found := edgesByKey[edgeKey{chainName: chain.ChainName, channelID: channel}]
+			if !found {
+				addIssue(IssueMissingChannel, chain.ChainName, counterpartyName, channel,
+					fmt.Sprintf("channel %s/%s not found on %s", transferPort, channel, chain.ChainName))
+				continue
+			}
+
+			if edge.State != channelStateOpen {
+				addIssue(IssueChannelNotOpen, chain.ChainName, counterpartyName, channel,
+					fmt.Sprintf("channel is in state %s", ChannelStateName(edge.State)))
+			}
+
+			if trackedID := apiutils.StringValue(edge.CounterpartyChainID); trackedID != counterparty.NodeInfo.ChainID {
+				addIssue(IssueChainIDMismatch, chain.ChainName, counterpartyName, channel,
+					fmt.Sprintf("channel client tracks chain id %q, %s has chain id %q", trackedID, counterpartyName, counterparty.NodeInfo.ChainID))
+			}
+
+			switch backChannel, ok := counterparty.PrimaryChannel[chain.ChainName]; {
+			case !ok:
+				addIssue(IssueNotReciprocal, chain.ChainName, counterpartyName, channel,
+					fmt.Sprintf("%s has no primary channel back to %s", counterpartyName, chain.ChainName))
+			case backChannel != edge.CounterChannelID:
+				addIssue(IssueNotReciprocal, chain.ChainName, counterpartyName, channel,
+					fmt.Sprintf("%s primary channel to %s is %s, expected %s", counterpartyName, chain.ChainName, backChannel, edge.CounterChannelID))
+			}
+		}
+	}
+
+	sort.SliceStable(ret.Issues, func(i, j int) bool {
+		if ret.Issues[i].ChainName != ret.Issues[j].ChainName {
+			return ret.Issues[i].ChainName < ret.Issues[j].ChainName
+		}
+
+		return ret.Issues[i].CounterpartyName < ret.Issues[j].CounterpartyName
+	})
+
+	return ret
+}
@@ -0,0 +1,85 @@
+package ibc
+
+import (
+	"testing"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditPrimaryChannels(t *testing.T) {
+	chains := []cns.Chain{
+		{
+			ChainName:      "cosmos-hub",
+			NodeInfo:       cns.NodeInfo{ChainID: "cosmoshub-4"},
+			PrimaryChannel: cns.DbStringMap{"osmosis": "channel-141", "juno": "channel-207", "akash": "channel-184"},
+		},
+		{
+			ChainName:      "osmosis",
+			NodeInfo:       cns.NodeInfo{ChainID: "osmosis-1"},
+			PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-0", "juno": "channel-42"},
+		},
+		{
+			ChainName:      "juno",
+			NodeInfo:       cns.NodeInfo{ChainID: "juno-1"},
+			PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-99", "osmosis": "channel-0"},
+		},
+	}
+
+	edges := []database.IBCChannelEdge{
+		// consistent pair
+		{ChainName: "cosmos-hub", ChannelID: "channel-141", CounterChannelID: "channel-0", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("osmosis-1")},
+		{ChainName: "osmosis", ChannelID: "channel-0", CounterChannelID: "channel-141", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("cosmoshub-4")},
+		// juno points back through channel-99 instead of channel-1
+		{ChainName: "cosmos-hub", ChannelID: "channel-207", CounterChannelID: "channel-1", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("juno-1")},
+		{ChainName: "juno", ChannelID: "channel-99", CounterChannelID: "channel-500", Port: "transfer", State: 4, CounterpartyChainID: apiutils.StringPtr("cosmoshub-4")},
+		// client tracks the wrong chain
+		{ChainName: "osmosis", ChannelID: "channel-42", CounterChannelID: "channel-0", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("juno-2")},
+		{ChainName: "juno", ChannelID: "channel-0", CounterChannelID: "channel-42", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("osmosis-1")},
+	}
+
+	audit := auditPrimaryChannels(chains, edges)
+	require.Equal(t, 7, audit.Checked)
+
+	type issue struct {
+		kind, chain, counterparty string
+	}
+
+	var issues []issue
+	for _, i := range audit.Issues {
+		issues = append(issues, issue{i.Kind, i.ChainName, i.CounterpartyName})
+	}
+
+	require.ElementsMatch(t, []issue{
+		{IssueUnknownCounterparty, "cosmos-hub", "akash"},
+		{IssueNotReciprocal, "cosmos-hub", "juno"},
+		{IssueChannelNotOpen, "juno", "cosmos-hub"},
+		{IssueNotReciprocal, "juno", "cosmos-hub"},
+		{IssueChainIDMismatch, "osmosis", "juno"},
+	}, issues)
+
+	t.Run("missing channels and primary channels", func(t *testing.T) {
+		chains := []cns.Chain{
+			{ChainName: "cosmos-hub", NodeInfo: cns.NodeInfo{ChainID: "cosmoshub-4"}, PrimaryChannel: cns.DbStringMap{"osmosis": "channel-141"}},
+			{ChainName: "osmosis", NodeInfo: cns.NodeInfo{ChainID: "osmosis-1"}},
+			// no channel to any other chain
+			{ChainName: "juno", NodeInfo: cns.NodeInfo{ChainID: "juno-1"}},
+		}
+
+		edges := []database.IBCChannelEdge{
+			{ChainName: "osmosis", ChannelID: "channel-0", CounterChannelID: "channel-141", Port: "transfer", State: 3, CounterpartyChainID: apiutils.StringPtr("cosmoshub-4")},
+			// closed channels don't need a primary channel
+			{ChainName: "osmosis", ChannelID: "channel-42", CounterChannelID: "channel-0", Port: "transfer", State: 4, CounterpartyChainID: apiutils.StringPtr("juno-1")},
+		}
+
+		audit := auditPrimaryChannels(chains, edges)
+		require.Equal(t, 1, audit.Checked)
+		require.Len(t, audit.Issues, 2)
+		require.Equal(t, IssueMissingChannel, audit.Issues[0].Kind)
+		require.Equal(t, IssueMissingPrimaryChannel, audit.Issues[1].Kind)
+		require.Equal(t, "osmosis", audit.Issues[1].ChainName)
+		require.Equal(t, "cosmos-hub", audit.Issues[1].CounterpartyName)
+	})
+}
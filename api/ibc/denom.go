@@ -0,0 +1,180 @@
+package ibc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+)
+
+const transferPort = "transfer"
+
+// denomTrace is the IBC trace of a token, made of the port/channel pairs it
+// went through and its base denom.
+type denomTrace struct {
+	Path      string
+	BaseDenom string
+}
+
+// parseDenomTrace splits a full denom trace such as
+// "transfer/channel-0/uatom" into its path and base denom.
+// Base denoms may contain slashes, so only leading port/channel pairs whose
+// channel is a channel identifier are treated as path.
+func parseDenomTrace(fullDenom string) (denomTrace, error) {
+	if fullDenom == "" {
+		return denomTrace{}, fmt.Errorf("empty denom")
+	}
+
+	parts := strings.Split(fullDenom, "/")
+
+	i := 0
+	for i+2 < len(parts) && parts[i] != "" && strings.HasPrefix(parts[i+1], "channel-") {
+		i += 2
+	}
+
+	base := strings.Join(parts[i:], "/")
+	if base == "" {
+		return denomTrace{}, fmt.Errorf("missing base denom in %s", fullDenom)
+	}
+
+	return denomTrace{
+		Path:      strings.Join(parts[:i], "/"),
+		BaseDenom: base,
+	}, nil
+}
+
+// FullPath returns the trace in the "path/base_denom" form the IBC denom hash
+// is computed on.
+func (t denomTrace) FullPath() string {
+	if t.Path == "" {
+		return t.BaseDenom
+	}
+
+	return t.Path + "/" + t.BaseDenom
+}
+
+// Hash returns the uppercased hex SHA256 hash of the trace.
+func (t denomTrace) Hash() string {
+	h := sha256.Sum256([]byte(t.FullPath()))
+	return strings.ToUpper(hex.EncodeToString(h[:]))
+}
+
+// IBCDenom returns the denom the trace is known as on chain, that is the base
+// denom for native tokens and "ibc/<hash>" otherwise.
+func (t denomTrace) IBCDenom() string {
+	if t.Path == "" {
+		return t.BaseDenom
+	}
+
+	return "ibc/" + t.Hash()
+}
+
+// firstHop returns the port and channel of the first element of the trace
+// path, the one the token was last received through.
+func (t denomTrace) firstHop() (string, string, error) {
+	elements := strings.SplitN(t.Path, "/", 3)
+	if len(elements) < 2 || elements[0] == "" || elements[1] == "" {
+		return "", "", fmt.Errorf("invalid trace path %s", t.Path)
+	}
+
+	return elements[0], elements[1], nil
+}
+
+// receive returns the trace the token gets once sent from the
+// sourcePort/sourceChannel end of a channel to its destPort/destChannel end.
+// If the token is sent back through the channel it came from, the last hop
+// is unwound instead of adding a new one.
+func (t denomTrace) receive(sourcePort, sourceChannel, destPort, destChannel string) denomTrace {
+	if sourceChannel != "" {
+		sourcePrefix := sourcePort + "/" + sourceChannel
+		if t.Path == sourcePrefix {
+			return denomTrace{BaseDenom: t.BaseDenom}
+		}
+
+		if strings.HasPrefix(t.Path, sourcePrefix+"/") {
+			return denomTrace{
+				Path:      strings.TrimPrefix(t.Path, sourcePrefix+"/"),
+				BaseDenom: t.BaseDenom,
+			}
+		}
+	}
+
+	path := destPort + "/" + destChannel
+	if t.Path != "" {
+		path += "/" + t.Path
+	}
+
+	return denomTrace{
+		Path:      path,
+		BaseDenom: t.BaseDenom,
+	}
+}
+
+// nativeChains returns the names of the chains declaring denom in CNS.
+func nativeChains(chains []cns.Chain, denom string) []string {
+	var ret []string
+	for _, c := range chains {
+		for _, d := range c.Denoms {
+			if d.Name == denom {
+				ret = append(ret, c.ChainName)
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// primaryChannelDenoms computes the denom trace arrives as on every chain
+// having a primary channel towards one of holders.
+func primaryChannelDenoms(chains []cns.Chain, trace denomTrace, holders []string) []PrimaryChannelDenom {
+	byName := make(map[string]cns.Chain, len(chains))
+	for _, c := range chains {
+		byName[c.ChainName] = c
+	}
+
+	ret := make([]PrimaryChannelDenom, 0)
+	for _, holder := range holders {
+		source, ok := byName[holder]
+		if !ok {
+			continue
+		}
+
+		for _, dest := range chains {
+			if dest.ChainName == holder {
+				continue
+			}
+
+			destChannel, ok := dest.PrimaryChannel[holder]
+			if !ok {
+				continue
+			}
+
+			// the sending side of the route is the source chain primary
+			// channel towards dest, when it has one
+			received := trace.receive(transferPort, source.PrimaryChannel[dest.ChainName], transferPort, destChannel)
+
+			ret = append(ret, PrimaryChannelDenom{
+				ChainName:       dest.ChainName,
+				SourceChainName: holder,
+				Channel:         destChannel,
+				Path:            received.Path,
+				BaseDenom:       received.BaseDenom,
+				Denom:           received.IBCDenom(),
+			})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].ChainName != ret[j].ChainName {
+			return ret[i].ChainName < ret[j].ChainName
+		}
+
+		return ret[i].SourceChainName < ret[j].SourceChainName
+	})
+
+	return ret
+}
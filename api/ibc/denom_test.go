@@ -0,0 +1,90 @@
+package ibc
+
+import (
+	"testing"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDenomTrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		denom    string
+		expected denomTrace
+		success  bool
+	}{
+		{"native denom", "uatom", denomTrace{BaseDenom: "uatom"}, true},
+		{"single hop", "transfer/channel-0/uatom", denomTrace{Path: "transfer/channel-0", BaseDenom: "uatom"}, true},
+		{"multi hop", "transfer/channel-1/transfer/channel-0/uatom", denomTrace{Path: "transfer/channel-1/transfer/channel-0", BaseDenom: "uatom"}, true},
+		{"base denom with slashes", "transfer/channel-0/gamm/pool/1", denomTrace{Path: "transfer/channel-0", BaseDenom: "gamm/pool/1"}, true},
+		{"empty denom", "", denomTrace{}, false},
+		{"missing base denom", "transfer/channel-0/", denomTrace{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseDenomTrace(tt.denom)
+			if !tt.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestDenomTraceHash(t *testing.T) {
+	trace := denomTrace{Path: "transfer/channel-0", BaseDenom: "uatom"}
+	require.Equal(t, "27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", trace.Hash())
+	require.Equal(t, "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", trace.IBCDenom())
+	require.Equal(t, "uatom", denomTrace{BaseDenom: "uatom"}.IBCDenom())
+}
+
+func TestPrimaryChannelDenoms(t *testing.T) {
+	chains := []cns.Chain{
+		{
+			ChainName:      "cosmos-hub",
+			PrimaryChannel: cns.DbStringMap{"osmosis": "channel-141", "akash": "channel-184"},
+			Denoms:         cns.DenomList{{Name: "uatom"}},
+		},
+		{
+			ChainName:      "osmosis",
+			PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-0", "akash": "channel-1"},
+		},
+		{
+			ChainName:      "akash",
+			PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-17"},
+		},
+	}
+
+	t.Run("native token", func(t *testing.T) {
+		trace := denomTrace{BaseDenom: "uatom"}
+		res := primaryChannelDenoms(chains, trace, nativeChains(chains, "uatom"))
+		require.Len(t, res, 2)
+
+		require.Equal(t, "akash", res[0].ChainName)
+		require.Equal(t, "transfer/channel-17", res[0].Path)
+
+		require.Equal(t, "osmosis", res[1].ChainName)
+		require.Equal(t, "cosmos-hub", res[1].SourceChainName)
+		require.Equal(t, "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", res[1].Denom)
+	})
+
+	t.Run("token unwinding to its origin", func(t *testing.T) {
+		trace := denomTrace{Path: "transfer/channel-0", BaseDenom: "uatom"}
+		res := primaryChannelDenoms(chains, trace, []string{"osmosis"})
+		require.Len(t, res, 1)
+
+		require.Equal(t, "cosmos-hub", res[0].ChainName)
+		require.Equal(t, "uatom", res[0].Denom)
+		require.Empty(t, res[0].Path)
+	})
+
+	t.Run("unknown holder", func(t *testing.T) {
+		res := primaryChannelDenoms(chains, denomTrace{BaseDenom: "uatom"}, []string{"juno"})
+		require.Empty(t, res)
+	})
+}
@@ -0,0 +1,214 @@
+package ibc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+)
+
+// ics20Version is the ICS-20 version the escrow addresses are derived from.
+const ics20Version = "ics20-1"
+
+// EscrowAddress returns the address holding the tokens sent out through
+// port/channel, as derived by the ICS-20 transfer module.
+func EscrowAddress(port, channel string) []byte {
+	preImage := append([]byte(ics20Version), 0)
+	preImage = append(preImage, port+"/"+channel...)
+
+	hash := sha256.Sum256(preImage)
+	return hash[:20]
+}
+
+// escrowChannel is a transfer channel along with its escrow address.
+type escrowChannel struct {
+	edge          database.IBCChannelEdge
+	hexAddress    string
+	bech32Address string
+}
+
+func escrowChannels(chains []cns.Chain, edges []database.IBCChannelEdge, chainName, channelID string) []escrowChannel {
+	prefixes := make(map[string]string, len(chains))
+	for _, c := range chains {
+		prefixes[c.ChainName] = c.NodeInfo.Bech32Config.Bech32PrefixAccAddr()
+	}
+
+	var ret []escrowChannel
+	for _, e := range edges {
+		if e.Port != transferPort ||
+			(chainName != "" && e.ChainName != chainName) ||
+			(channelID != "" && e.ChannelID != channelID) {
+			continue
+		}
+
+		addr := EscrowAddress(e.Port, e.ChannelID)
+		ec := escrowChannel{
+			edge:       e,
+			hexAddress: hex.EncodeToString(addr),
+		}
+
+		if prefix := prefixes[e.ChainName]; prefix != "" {
+			// an invalid prefix only prevents showing the bech32 address
+			ec.bech32Address, _ = bech32.ConvertAndEncode(prefix, addr)
+		}
+
+		ret = append(ret, ec)
+	}
+
+	return ret
+}
+
+// balanceAmount parses the amount of a tracelistener balance, which may or
+// may not be suffixed by its denom.
+func balanceAmount(b tracelistener.BalanceRow) (sdktypes.Int, bool) {
+	return sdktypes.NewIntFromString(strings.TrimSuffix(b.Amount, b.Denom))
+}
+
+// reconcileEscrows compares the tokens escrowed on each channel against the
+// vouchers of these tokens held on the counterparty chain.
+// traces holds the denom traces of the escrowed IBC denoms, indexed by chain
+// name and lowercase hash.
+func reconcileEscrows(
+	channels []escrowChannel,
+	escrowBalances []tracelistener.BalanceRow,
+	voucherTotals []database.DenomTotal,
+	traces map[string]map[string]tracelistener.IBCDenomTraceRow,
+) []EscrowChannel {
+	type balanceKey struct {
+		chainName string
+		key       string
+	}
+
+	escrowed := map[balanceKey][]tracelistener.BalanceRow{}
+	for _, b := range escrowBalances {
+		k := balanceKey{chainName: b.ChainName, key: b.Address}
+		escrowed[k] = append(escrowed[k], b)
+	}
+
+	vouchers := map[balanceKey]sdktypes.Int{}
+	for _, t := range voucherTotals {
+		amount, ok := sdktypes.NewIntFromString(t.Amount)
+		if !ok {
+			continue
+		}
+
+		vouchers[balanceKey{chainName: t.ChainName, key: t.Denom}] = amount
+	}
+
+	ret := make([]EscrowChannel, 0, len(channels))
+	for _, ch := range channels {
+		ec := EscrowChannel{
+			ChainName:             ch.edge.ChainName,
+			ChannelID:             ch.edge.ChannelID,
+			CounterpartyName:      apiutils.StringValue(ch.edge.CounterpartyChainName),
+			CounterpartyChannelID: ch.edge.CounterChannelID,
+			EscrowAddress:         ch.bech32Address,
+			EscrowAddressHex:      ch.hexAddress,
+			Denoms:                []EscrowDenom{},
+		}
+
+		for _, b := range escrowed[balanceKey{chainName: ch.edge.ChainName, key: ch.hexAddress}] {
+			amount, ok := balanceAmount(b)
+			if !ok || amount.IsZero() {
+				continue
+			}
+
+			trace, resolved := escrowedTrace(b.Denom, traces[ch.edge.ChainName])
+			ed := EscrowDenom{
+				Denom:      b.Denom,
+				BaseDenom:  trace.BaseDenom,
+				Path:       trace.Path,
+				Escrowed:   amount.String(),
+				Unresolved: !resolved,
+			}
+
+			// vouchers of tokens whose trace is unknown can't be computed
+			if resolved && ec.CounterpartyName != "" {
+				voucher := trace.receive(transferPort, ch.edge.ChannelID, transferPort, ch.edge.CounterChannelID)
+				ed.VoucherDenom = voucher.IBCDenom()
+
+				supply := sdktypes.ZeroInt()
+				if v, ok := vouchers[balanceKey{chainName: ec.CounterpartyName, key: ed.VoucherDenom}]; ok {
+					supply = v
+				}
+
+				ed.VoucherSupply = supply.String()
+				ed.Difference = amount.Sub(supply).String()
+				ed.Consistent = amount.Equal(supply)
+			}
+
+			ec.Denoms = append(ec.Denoms, ed)
+		}
+
+		sort.Slice(ec.Denoms, func(i, j int) bool {
+			return ec.Denoms[i].Denom < ec.Denoms[j].Denom
+		})
+
+		ret = append(ret, ec)
+	}
+
+	return ret
+}
+
+// escrowedTrace returns the trace of denom, as escrowed on a chain whose
+// denom traces are traces, or false if denom is an IBC denom of unknown
+// trace.
+func escrowedTrace(denom string, traces map[string]tracelistener.IBCDenomTraceRow) (denomTrace, bool) {
+	hash := strings.TrimPrefix(denom, "ibc/")
+	if hash == denom {
+		return denomTrace{BaseDenom: denom}, true
+	}
+
+	t, ok := traces[strings.ToLower(hash)]
+	if !ok {
+		return denomTrace{}, false
+	}
+
+	return denomTrace{
+		Path:      t.Path,
+		BaseDenom: t.BaseDenom,
+	}, true
+}
+
+// voucherDenoms returns the denoms vouchers of the escrowed tokens are known
+// as on the counterparty chains.
+func voucherDenoms(channels []escrowChannel, escrowBalances []tracelistener.BalanceRow, traces map[string]map[string]tracelistener.IBCDenomTraceRow) []string {
+	byAddress := map[string]escrowChannel{}
+	for _, ch := range channels {
+		byAddress[ch.edge.ChainName+"/"+ch.hexAddress] = ch
+	}
+
+	set := map[string]struct{}{}
+	for _, b := range escrowBalances {
+		ch, ok := byAddress[b.ChainName+"/"+b.Address]
+		if !ok || ch.edge.CounterpartyChainName == nil {
+			continue
+		}
+
+		if amount, ok := balanceAmount(b); !ok || amount.IsZero() {
+			continue
+		}
+
+		trace, ok := escrowedTrace(b.Denom, traces[b.ChainName])
+		if !ok {
+			continue
+		}
+
+		set[trace.receive(transferPort, ch.edge.ChannelID, transferPort, ch.edge.CounterChannelID).IBCDenom()] = struct{}{}
+	}
+
+	ret := make([]string, 0, len(set))
+	for d := range set {
+		ret = append(ret, d)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,110 @@
+package ibc
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscrowAddress(t *testing.T) {
+	addr, err := bech32.ConvertAndEncode("cosmos", EscrowAddress("transfer", "channel-141"))
+	require.NoError(t, err)
+	require.Equal(t, "cosmos1x54ltnyg88k0ejmk8ytwrhd3ltm84xehrnlslf", addr)
+}
+
+func TestReconcileEscrows(t *testing.T) {
+	chains := []cns.Chain{
+		{ChainName: "cosmos-hub", NodeInfo: cns.NodeInfo{Bech32Config: cns.Bech32Config{MainPrefix: "cosmos"}}},
+		{ChainName: "osmosis", NodeInfo: cns.NodeInfo{Bech32Config: cns.Bech32Config{MainPrefix: "osmo"}}},
+	}
+
+	edges := []database.IBCChannelEdge{
+		{ChainName: "cosmos-hub", ChannelID: "channel-141", CounterChannelID: "channel-0", Port: "transfer", State: 3, CounterpartyChainName: apiutils.StringPtr("osmosis")},
+		{ChainName: "cosmos-hub", ChannelID: "channel-190", CounterChannelID: "channel-1", Port: "icahost", State: 3, CounterpartyChainName: apiutils.StringPtr("osmosis")},
+		{ChainName: "osmosis", ChannelID: "channel-0", CounterChannelID: "channel-141", Port: "transfer", State: 3, CounterpartyChainName: apiutils.StringPtr("cosmos-hub")},
+		{ChainName: "osmosis", ChannelID: "channel-9", CounterChannelID: "channel-3", Port: "transfer", State: 3},
+	}
+
+	channels := escrowChannels(chains, edges, "cosmos-hub", "")
+	require.Len(t, channels, 1)
+	require.Equal(t, "cosmos1x54ltnyg88k0ejmk8ytwrhd3ltm84xehrnlslf", channels[0].bech32Address)
+
+	channels = escrowChannels(chains, edges, "", "")
+	require.Len(t, channels, 3)
+
+	hubEscrow := hex.EncodeToString(EscrowAddress("transfer", "channel-141"))
+	osmoEscrow := hex.EncodeToString(EscrowAddress("transfer", "channel-9"))
+
+	junoTrace := denomTrace{Path: "transfer/channel-207", BaseDenom: "ujuno"}
+	traces := map[string]map[string]tracelistener.IBCDenomTraceRow{
+		"cosmos-hub": {
+			strings.ToLower(junoTrace.Hash()): {Path: junoTrace.Path, BaseDenom: junoTrace.BaseDenom, Hash: junoTrace.Hash()},
+		},
+	}
+
+	escrowBalances := []tracelistener.BalanceRow{
+		{Address: hubEscrow, Amount: "1000uatom", Denom: "uatom", TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{ChainName: "cosmos-hub"}},
+		{Address: hubEscrow, Amount: "50" + junoTrace.IBCDenom(), Denom: junoTrace.IBCDenom(), TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{ChainName: "cosmos-hub"}},
+		{Address: hubEscrow, Amount: "0ustake", Denom: "ustake", TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{ChainName: "cosmos-hub"}},
+		{Address: hubEscrow, Amount: "3ibc/ABCDEF", Denom: "ibc/ABCDEF", TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{ChainName: "cosmos-hub"}},
+		{Address: osmoEscrow, Amount: "7uosmo", Denom: "uosmo", TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{ChainName: "osmosis"}},
+	}
+
+	atomVoucher := "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"
+	junoVoucher := denomTrace{Path: "transfer/channel-0/transfer/channel-207", BaseDenom: "ujuno"}.IBCDenom()
+
+	require.Equal(t, []string{atomVoucher, junoVoucher}, voucherDenoms(channels[:1], escrowBalances, traces))
+
+	voucherTotals := []database.DenomTotal{
+		{ChainName: "osmosis", Denom: atomVoucher, Amount: "1000", Holders: 2},
+		{ChainName: "osmosis", Denom: junoVoucher, Amount: "60", Holders: 1},
+		// same denom on another chain, not a voucher of the hub escrow
+		{ChainName: "juno", Denom: atomVoucher, Amount: "5", Holders: 1},
+	}
+
+	res := reconcileEscrows(channels, escrowBalances, voucherTotals, traces)
+	require.Len(t, res, 3)
+
+	require.Equal(t, "cosmos-hub", res[0].ChainName)
+	require.Equal(t, []EscrowDenom{
+		// unknown trace, vouchers can't be reconciled
+		{
+			Denom:      "ibc/ABCDEF",
+			Escrowed:   "3",
+			Unresolved: true,
+		},
+		{
+			Denom:         junoTrace.IBCDenom(),
+			BaseDenom:     "ujuno",
+			Path:          "transfer/channel-207",
+			Escrowed:      "50",
+			VoucherDenom:  junoVoucher,
+			VoucherSupply: "60",
+			Difference:    "-10",
+		},
+		{
+			Denom:         "uatom",
+			BaseDenom:     "uatom",
+			Escrowed:      "1000",
+			VoucherDenom:  atomVoucher,
+			VoucherSupply: "1000",
+			Difference:    "0",
+			Consistent:    true,
+		},
+	}, res[0].Denoms)
+
+	require.Empty(t, res[1].Denoms)
+
+	// unknown counterparty, vouchers can't be reconciled
+	require.Equal(t, "channel-9", res[2].ChannelID)
+	require.Equal(t, []EscrowDenom{
+		{Denom: "uosmo", BaseDenom: "uosmo", Escrowed: "7"},
+	}, res[2].Denoms)
+}
@@ -0,0 +1,136 @@
+package ibc
+
+type DenomHashResponse struct {
+	Path      string `json:"path,omitempty"`
+	BaseDenom string `json:"base_denom"`
+	// Denom is the denom the trace is known as, in the "ibc/<HASH>" form
+	// for non-native tokens.
+	Denom string `json:"denom"`
+	Hash  string `json:"hash,omitempty"`
+	// Chains lists the enabled chains holding a denom trace for Hash.
+	Chains               []string              `json:"chains"`
+	PrimaryChannelDenoms []PrimaryChannelDenom `json:"primary_channel_denoms"`
+}
+
+// PrimaryChannelDenom is the denom a token gets on ChainName once received
+// from SourceChainName through ChainName primary channel.
+type PrimaryChannelDenom struct {
+	ChainName       string `json:"chain_name"`
+	SourceChainName string `json:"source_chain_name"`
+	Channel         string `json:"channel"`
+	Path            string `json:"path,omitempty"`
+	BaseDenom       string `json:"base_denom"`
+	Denom           string `json:"denom"`
+}
+
+type RouteResponse struct {
+	Route Route `json:"route"`
+}
+
+// Route describes how to move Denom from From to To.
+// Hops unwinding the token back to its OriginChain come first, followed by
+// the hops forwarding it through primary channels.
+type Route struct {
+	From          string     `json:"from"`
+	To            string     `json:"to"`
+	Denom         string     `json:"denom"`
+	BaseDenom     string     `json:"base_denom"`
+	OriginChain   string     `json:"origin_chain,omitempty"`
+	Hops          []RouteHop `json:"hops"`
+	ReceivedDenom string     `json:"received_denom"`
+	ReceivedPath  string     `json:"received_path,omitempty"`
+}
+
+type RouteHop struct {
+	Kind                string `json:"kind"`
+	ChainName           string `json:"chain_name"`
+	CounterpartyName    string `json:"counterparty_name"`
+	Port                string `json:"port"`
+	Channel             string `json:"channel"`
+	CounterpartyChannel string `json:"counterparty_channel"`
+	Denom               string `json:"denom"`
+	ReceivedDenom       string `json:"received_denom"`
+}
+
+type TopologyResponse struct {
+	Topology Topology `json:"topology"`
+}
+
+type Topology struct {
+	Chains   []TopologyChain   `json:"chains"`
+	Channels []TopologyChannel `json:"channels"`
+}
+
+type TopologyChain struct {
+	ChainName string `json:"chain_name"`
+	ChainID   string `json:"chain_id,omitempty"`
+	Enabled   bool   `json:"enabled"`
+}
+
+type TopologyChannel struct {
+	ChainName           string `json:"chain_name"`
+	ChannelID           string `json:"channel_id"`
+	CounterChannelID    string `json:"counter_channel_id"`
+	Port                string `json:"port"`
+	State               string `json:"state"`
+	ConnectionID        string `json:"connection_id,omitempty"`
+	ClientID            string `json:"client_id,omitempty"`
+	CounterpartyChainID string `json:"counterparty_chain_id,omitempty"`
+	CounterpartyName    string `json:"counterparty_name,omitempty"`
+	Open                bool   `json:"open"`
+	// Primary is true if the channel is a CNS primary channel.
+	Primary bool `json:"primary"`
+	// Unused is true if the channel is open but not a CNS primary channel.
+	Unused bool `json:"unused"`
+}
+
+type PrimaryChannelAuditResponse struct {
+	Audit PrimaryChannelAudit `json:"audit"`
+}
+
+type PrimaryChannelAudit struct {
+	// Checked is the number of primary channels checked.
+	Checked int                   `json:"checked"`
+	Issues  []PrimaryChannelIssue `json:"issues"`
+}
+
+type PrimaryChannelIssue struct {
+	Kind             string `json:"kind"`
+	ChainName        string `json:"chain_name"`
+	CounterpartyName string `json:"counterparty_name"`
+	Channel          string `json:"channel,omitempty"`
+	Details          string `json:"details"`
+}
+
+type EscrowResponse struct {
+	Channels []EscrowChannel `json:"channels"`
+}
+
+// EscrowChannel holds the tokens escrowed by ChainName on a transfer channel.
+type EscrowChannel struct {
+	ChainName             string        `json:"chain_name"`
+	ChannelID             string        `json:"channel_id"`
+	CounterpartyName      string        `json:"counterparty_name,omitempty"`
+	CounterpartyChannelID string        `json:"counterparty_channel_id"`
+	EscrowAddress         string        `json:"escrow_address,omitempty"`
+	EscrowAddressHex      string        `json:"escrow_address_hex"`
+	Denoms                []EscrowDenom `json:"denoms"`
+}
+
+// EscrowDenom reconciles the amount of Denom escrowed on a channel with the
+// supply of its voucher on the counterparty chain.
+// Voucher fields are empty if the counterparty chain is unknown.
+type EscrowDenom struct {
+	Denom         string `json:"denom"`
+	BaseDenom     string `json:"base_denom"`
+	Path          string `json:"path,omitempty"`
+	Escrowed      string `json:"escrowed"`
+	VoucherDenom  string `json:"voucher_denom,omitempty"`
+	VoucherSupply string `json:"voucher_supply,omitempty"`
+	// Difference is the escrowed amount minus the voucher supply.
+	Difference string `json:"difference,omitempty"`
+	Consistent bool   `json:"consistent"`
+	// Unresolved is set when Denom is an IBC denom of unknown trace, in
+	// which case it isn't reconciled.
+	Unresolved bool `json:"unresolved,omitempty"`
+}
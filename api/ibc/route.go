@@ -0,0 +1,188 @@
+package ibc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+)
+
+const (
+	hopUnwind  = "unwind"
+	hopForward = "forward"
+)
+
+// channelEnd is the counterparty side of a chain channel.
+type channelEnd struct {
+	ChainName string
+	Channel   string
+}
+
+// channelResolverFunc returns the counterparty end of channel on chainName.
+type channelResolverFunc func(ctx context.Context, chainName, channel string) (channelEnd, error)
+
+// routePlanner plans IBC transfers between enabled chains.
+// Tokens are first unwound back to their origin chain following their trace
+// path, then forwarded through primary channels, so that they never end up
+// wrapped more than needed.
+type routePlanner struct {
+	chains   map[string]cns.Chain
+	resolver channelResolverFunc
+}
+
+func newRoutePlanner(chains []cns.Chain, resolver channelResolverFunc) *routePlanner {
+	byName := make(map[string]cns.Chain, len(chains))
+	for _, c := range chains {
+		byName[c.ChainName] = c
+	}
+
+	return &routePlanner{
+		chains:   byName,
+		resolver: resolver,
+	}
+}
+
+// plan returns the route moving the token known as trace on from to chain
+// to.
+func (p *routePlanner) plan(ctx context.Context, from, to string, trace denomTrace) (Route, error) {
+	if _, ok := p.chains[from]; !ok {
+		return Route{}, fmt.Errorf("chain %s not found", from)
+	}
+
+	if _, ok := p.chains[to]; !ok {
+		return Route{}, fmt.Errorf("chain %s not found", to)
+	}
+
+	route := Route{
+		From:      from,
+		To:        to,
+		Denom:     trace.IBCDenom(),
+		BaseDenom: trace.BaseDenom,
+		Hops:      []RouteHop{},
+	}
+
+	current := from
+	for trace.Path != "" {
+		if current == to {
+			// the token reached the destination while being unwound, no
+			// need to move it any further
+			break
+		}
+
+		port, channel, err := trace.firstHop()
+		if err != nil {
+			return Route{}, fmt.Errorf("cannot unwind %s from %s: %w", trace.IBCDenom(), current, err)
+		}
+
+		end, err := p.resolver(ctx, current, channel)
+		if err != nil {
+			return Route{}, fmt.Errorf("cannot unwind %s from %s through %s/%s: %w", trace.IBCDenom(), current, port, channel, err)
+		}
+
+		received := trace.receive(port, channel, port, end.Channel)
+		route.Hops = append(route.Hops, RouteHop{
+			Kind:                hopUnwind,
+			ChainName:           current,
+			CounterpartyName:    end.ChainName,
+			Port:                port,
+			Channel:             channel,
+			CounterpartyChannel: end.Channel,
+			Denom:               trace.IBCDenom(),
+			ReceivedDenom:       received.IBCDenom(),
+		})
+
+		trace = received
+		current = end.ChainName
+	}
+
+	if trace.Path == "" {
+		route.OriginChain = current
+	}
+
+	if current != to {
+		chainPath, err := p.shortestPath(current, to)
+		if err != nil {
+			return Route{}, err
+		}
+
+		for i := 0; i < len(chainPath)-1; i++ {
+			src, dst := chainPath[i], chainPath[i+1]
+			channel := p.chains[src].PrimaryChannel[dst]
+
+			end, err := p.resolver(ctx, src, channel)
+			if err != nil {
+				return Route{}, fmt.Errorf("cannot forward from %s through %s: %w", src, channel, err)
+			}
+
+			if end.ChainName != dst {
+				return Route{}, fmt.Errorf("primary channel %s of %s leads to %s instead of %s", channel, src, end.ChainName, dst)
+			}
+
+			received := trace.receive(transferPort, channel, transferPort, end.Channel)
+			route.Hops = append(route.Hops, RouteHop{
+				Kind:                hopForward,
+				ChainName:           src,
+				CounterpartyName:    dst,
+				Port:                transferPort,
+				Channel:             channel,
+				CounterpartyChannel: end.Channel,
+				Denom:               trace.IBCDenom(),
+				ReceivedDenom:       received.IBCDenom(),
+			})
+
+			trace = received
+		}
+	}
+
+	route.ReceivedDenom = trace.IBCDenom()
+	route.ReceivedPath = trace.Path
+
+	return route, nil
+}
+
+// shortestPath returns the chains to go through to reach to from from using
+// primary channels only, both included.
+func (p *routePlanner) shortestPath(from, to string) ([]string, error) {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == to {
+			var path []string
+			for c := to; c != ""; c = prev[c] {
+				path = append([]string{c}, path...)
+			}
+
+			return path, nil
+		}
+
+		for _, next := range sortedKeys(p.chains[current].PrimaryChannel) {
+			if _, enabled := p.chains[next]; !enabled {
+				continue
+			}
+
+			if _, seen := prev[next]; seen {
+				continue
+			}
+
+			prev[next] = current
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, fmt.Errorf("no route found from %s to %s through primary channels", from, to)
+}
+
+func sortedKeys(m cns.DbStringMap) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
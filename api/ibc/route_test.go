@@ -0,0 +1,171 @@
+package ibc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoutePlanner() *routePlanner {
+	chains := []cns.Chain{
+		{ChainName: "cosmos-hub", PrimaryChannel: cns.DbStringMap{"osmosis": "channel-141", "juno": "channel-207"}},
+		{ChainName: "osmosis", PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-0", "juno": "channel-42"}},
+		{ChainName: "juno", PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-1", "osmosis": "channel-0"}},
+		{ChainName: "isolated"},
+	}
+
+	ends := map[string]channelEnd{
+		"cosmos-hub/channel-141": {ChainName: "osmosis", Channel: "channel-0"},
+		"osmosis/channel-0":      {ChainName: "cosmos-hub", Channel: "channel-141"},
+		"cosmos-hub/channel-207": {ChainName: "juno", Channel: "channel-1"},
+		"juno/channel-1":         {ChainName: "cosmos-hub", Channel: "channel-207"},
+		"osmosis/channel-42":     {ChainName: "juno", Channel: "channel-0"},
+		"juno/channel-0":         {ChainName: "osmosis", Channel: "channel-42"},
+	}
+
+	return newRoutePlanner(chains, func(_ context.Context, chainName, channel string) (channelEnd, error) {
+		end, ok := ends[chainName+"/"+channel]
+		if !ok {
+			return channelEnd{}, fmt.Errorf("no destination chain found for %s -> %s -> destination", chainName, channel)
+		}
+
+		return end, nil
+	})
+}
+
+func TestRoutePlanner(t *testing.T) {
+	// ATOM sent from cosmos-hub to juno, then from juno to osmosis
+	doubleWrapped := denomTrace{Path: "transfer/channel-42/transfer/channel-1", BaseDenom: "uatom"}
+
+	tests := []struct {
+		name          string
+		from          string
+		to            string
+		trace         denomTrace
+		expectedHops  []string
+		receivedDenom string
+		originChain   string
+		success       bool
+	}{
+		{
+			"native token forwarded through primary channel",
+			"cosmos-hub",
+			"osmosis",
+			denomTrace{BaseDenom: "uatom"},
+			[]string{"forward cosmos-hub/channel-141 -> osmosis/channel-0"},
+			"ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2",
+			"cosmos-hub",
+			true,
+		},
+		{
+			"voucher unwound to its origin",
+			"osmosis",
+			"cosmos-hub",
+			doubleWrapped,
+			[]string{
+				"unwind osmosis/channel-42 -> juno/channel-0",
+				"unwind juno/channel-1 -> cosmos-hub/channel-207",
+			},
+			"uatom",
+			"cosmos-hub",
+			true,
+		},
+		{
+			"voucher unwound up to the destination",
+			"osmosis",
+			"juno",
+			doubleWrapped,
+			[]string{"unwind osmosis/channel-42 -> juno/channel-0"},
+			denomTrace{Path: "transfer/channel-1", BaseDenom: "uatom"}.IBCDenom(),
+			"",
+			true,
+		},
+		{
+			"voucher unwound then forwarded",
+			"juno",
+			"osmosis",
+			denomTrace{Path: "transfer/channel-1", BaseDenom: "uatom"},
+			[]string{
+				"unwind juno/channel-1 -> cosmos-hub/channel-207",
+				"forward cosmos-hub/channel-141 -> osmosis/channel-0",
+			},
+			"ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2",
+			"cosmos-hub",
+			true,
+		},
+		{
+			"same chain",
+			"osmosis",
+			"osmosis",
+			doubleWrapped,
+			[]string{},
+			doubleWrapped.IBCDenom(),
+			"",
+			true,
+		},
+		{
+			"unknown trace channel",
+			"osmosis",
+			"cosmos-hub",
+			denomTrace{Path: "transfer/channel-999", BaseDenom: "uatom"},
+			nil,
+			"",
+			"",
+			false,
+		},
+		{
+			"malformed trace path",
+			"osmosis",
+			"cosmos-hub",
+			denomTrace{Path: "transfer", BaseDenom: "uatom"},
+			nil,
+			"",
+			"",
+			false,
+		},
+		{
+			"no primary channel route",
+			"cosmos-hub",
+			"isolated",
+			denomTrace{BaseDenom: "uatom"},
+			nil,
+			"",
+			"",
+			false,
+		},
+		{
+			"unknown chain",
+			"cosmos-hub",
+			"unknown",
+			denomTrace{BaseDenom: "uatom"},
+			nil,
+			"",
+			"",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := testRoutePlanner().plan(context.Background(), tt.from, tt.to, tt.trace)
+			if !tt.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			hops := make([]string, 0, len(route.Hops))
+			for _, h := range route.Hops {
+				hops = append(hops, fmt.Sprintf("%s %s/%s -> %s/%s", h.Kind, h.ChainName, h.Channel, h.CounterpartyName, h.CounterpartyChannel))
+			}
+
+			require.Equal(t, tt.expectedHops, hops)
+			require.Equal(t, tt.receivedDenom, route.ReceivedDenom)
+			require.Equal(t, tt.originChain, route.OriginChain)
+		})
+	}
+}
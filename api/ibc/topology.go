@@ -0,0 +1,148 @@
+package ibc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/cns"
+)
+
+// mimeGraphviz is the media type of Graphviz DOT documents.
+const mimeGraphviz = "text/vnd.graphviz"
+
+// channelStates maps the ibc-go channel State enum to its name.
+var channelStates = map[int32]string{
+	0: "STATE_UNINITIALIZED_UNSPECIFIED",
+	1: "STATE_INIT",
+	2: "STATE_TRYOPEN",
+	3: "STATE_OPEN",
+	4: "STATE_CLOSED",
+}
+
+const channelStateOpen int32 = 3
+
+// ChannelStateName returns the name of an ibc-go channel state.
+func ChannelStateName(state int32) string {
+	if name, ok := channelStates[state]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("STATE_%d", state)
+}
+
+// buildTopology assembles the IBC graph of chains out of the channel edges.
+func buildTopology(chains []cns.Chain, edges []database.IBCChannelEdge) Topology {
+	primaryChannels := make(map[string]map[string]bool, len(chains))
+	nodes := make(map[string]TopologyChain, len(chains))
+	for _, c := range chains {
+		nodes[c.ChainName] = TopologyChain{
+			ChainName: c.ChainName,
+			ChainID:   c.NodeInfo.ChainID,
+			Enabled:   c.Enabled,
+		}
+
+		primaryChannels[c.ChainName] = make(map[string]bool, len(c.PrimaryChannel))
+		for _, channel := range c.PrimaryChannel {
+			primaryChannels[c.ChainName][channel] = true
+		}
+	}
+
+	ret := Topology{
+		Chains:   []TopologyChain{},
+		Channels: make([]TopologyChannel, 0, len(edges)),
+	}
+
+	for _, e := range edges {
+		ch := TopologyChannel{
+			ChainName:           e.ChainName,
+			ChannelID:           e.ChannelID,
+			CounterChannelID:    e.CounterChannelID,
+			Port:                e.Port,
+			State:               ChannelStateName(e.State),
+			ConnectionID:        apiutils.StringValue(e.ConnectionID),
+			ClientID:            apiutils.StringValue(e.ClientID),
+			CounterpartyChainID: apiutils.StringValue(e.CounterpartyChainID),
+			CounterpartyName:    apiutils.StringValue(e.CounterpartyChainName),
+			Open:                e.State == channelStateOpen,
+			Primary:             primaryChannels[e.ChainName][e.ChannelID],
+		}
+		ch.Unused = ch.Open && !ch.Primary
+
+		if _, ok := nodes[e.ChainName]; !ok {
+			nodes[e.ChainName] = TopologyChain{ChainName: e.ChainName, ChainID: e.ChainID}
+		}
+
+		if ch.CounterpartyName != "" {
+			if _, ok := nodes[ch.CounterpartyName]; !ok {
+				nodes[ch.CounterpartyName] = TopologyChain{ChainName: ch.CounterpartyName}
+			}
+		}
+
+		ret.Channels = append(ret.Channels, ch)
+	}
+
+	for _, n := range nodes {
+		ret.Chains = append(ret.Chains, n)
+	}
+
+	sort.Slice(ret.Chains, func(i, j int) bool {
+		return ret.Chains[i].ChainName < ret.Chains[j].ChainName
+	})
+
+	return ret
+}
+
+// dot renders the topology as a Graphviz DOT digraph. Primary channels are
+// drawn bold, open but unused channels dashed and the others dotted.
+// Channels whose counterparty can't be resolved point to a node named after
+// the counterparty chain ID, if any.
+func (t Topology) dot() string {
+	var b strings.Builder
+
+	b.WriteString("digraph ibc {\n")
+	b.WriteString("\trankdir=LR;\n")
+
+	for _, c := range t.Chains {
+		style := "solid"
+		if !c.Enabled {
+			style = "dashed"
+		}
+
+		fmt.Fprintf(&b, "\t%q [label=%q, style=%s];\n", c.ChainName, c.ChainName, style)
+	}
+
+	for _, ch := range t.Channels {
+		counterparty := ch.CounterpartyName
+		if counterparty == "" {
+			counterparty = "unknown"
+			if ch.CounterpartyChainID != "" {
+				counterparty = "unknown:" + ch.CounterpartyChainID
+			}
+		}
+
+		style, color := "dotted", "gray"
+		switch {
+		case ch.Primary:
+			style, color = "bold", "blue"
+		case ch.Unused:
+			style, color = "dashed", "orange"
+		}
+
+		fmt.Fprintf(
+			&b,
+			"\t%q -> %q [label=%q, style=%s, color=%s];\n",
+			ch.ChainName,
+			counterparty,
+			ch.Port+"/"+ch.ChannelID,
+			style,
+			color,
+		)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
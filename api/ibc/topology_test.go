@@ -0,0 +1,70 @@
+package ibc
+
+import (
+	"testing"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTopology(t *testing.T) {
+	chains := []cns.Chain{
+		{ChainName: "cosmos-hub", Enabled: true, PrimaryChannel: cns.DbStringMap{"osmosis": "channel-141"}, NodeInfo: cns.NodeInfo{ChainID: "cosmoshub-4"}},
+		{ChainName: "osmosis", Enabled: true, PrimaryChannel: cns.DbStringMap{"cosmos-hub": "channel-0"}, NodeInfo: cns.NodeInfo{ChainID: "osmosis-1"}},
+	}
+	edges := []database.IBCChannelEdge{
+		{
+			ChainName: "cosmos-hub", ChainID: "cosmoshub-4", ChannelID: "channel-141", CounterChannelID: "channel-0", Port: "transfer", State: 3,
+			ConnectionID: apiutils.StringPtr("connection-257"), ClientID: apiutils.StringPtr("07-tendermint-259"),
+			CounterpartyChainID: apiutils.StringPtr("osmosis-1"), CounterpartyChainName: apiutils.StringPtr("osmosis"),
+		},
+		{
+			ChainName: "cosmos-hub", ChainID: "cosmoshub-4", ChannelID: "channel-5", CounterChannelID: "channel-9", Port: "transfer", State: 3,
+			CounterpartyChainID: apiutils.StringPtr("juno-1"),
+		},
+		{
+			ChainName: "osmosis", ChainID: "osmosis-1", ChannelID: "channel-0", CounterChannelID: "channel-141", Port: "transfer", State: 3,
+			CounterpartyChainID: apiutils.StringPtr("cosmoshub-4"), CounterpartyChainName: apiutils.StringPtr("cosmos-hub"),
+		},
+		{
+			ChainName: "osmosis", ChainID: "osmosis-1", ChannelID: "channel-7", CounterChannelID: "channel-1", Port: "transfer", State: 4,
+			CounterpartyChainID: apiutils.StringPtr("akashnet-2"), CounterpartyChainName: apiutils.StringPtr("akash"),
+		},
+	}
+
+	topology := buildTopology(chains, edges)
+
+	require.Equal(t, []TopologyChain{
+		{ChainName: "akash"},
+		{ChainName: "cosmos-hub", ChainID: "cosmoshub-4", Enabled: true},
+		{ChainName: "osmosis", ChainID: "osmosis-1", Enabled: true},
+	}, topology.Chains)
+
+	require.Len(t, topology.Channels, 4)
+
+	primary := topology.Channels[0]
+	require.True(t, primary.Primary)
+	require.False(t, primary.Unused)
+	require.Equal(t, "STATE_OPEN", primary.State)
+	require.Equal(t, "connection-257", primary.ConnectionID)
+	require.Equal(t, "osmosis", primary.CounterpartyName)
+
+	unused := topology.Channels[1]
+	require.False(t, unused.Primary)
+	require.True(t, unused.Unused)
+	require.Empty(t, unused.CounterpartyName)
+
+	closed := topology.Channels[3]
+	require.False(t, closed.Open)
+	require.False(t, closed.Unused)
+	require.Equal(t, "STATE_CLOSED", closed.State)
+
+	dot := topology.dot()
+	require.Contains(t, dot, "digraph ibc {")
+	require.Contains(t, dot, `"akash" [label="akash", style=dashed];`)
+	require.Contains(t, dot, `"cosmos-hub" -> "osmosis" [label="transfer/channel-141", style=bold, color=blue];`)
+	require.Contains(t, dot, `"cosmos-hub" -> "unknown:juno-1" [label="transfer/channel-5", style=dashed, color=orange];`)
+	require.Contains(t, dot, `"osmosis" -> "akash" [label="transfer/channel-7", style=dotted, color=gray];`)
+}
@@ -6,8 +6,11 @@ import (
 
 	"github.com/emerishq/demeris-api-server/api/block"
 	"github.com/emerishq/demeris-api-server/api/cached"
+	"github.com/emerishq/demeris-api-server/api/config"
+	"github.com/emerishq/demeris-api-server/api/ibc"
 	"github.com/emerishq/demeris-api-server/api/liquidity"
 	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ratelimit"
 	"github.com/emerishq/demeris-api-server/lib/stringcache"
 	"github.com/emerishq/demeris-api-server/sdkservice"
 	"github.com/emerishq/demeris-api-server/usecase"
@@ -27,6 +30,7 @@ import (
 	"github.com/emerishq/demeris-api-server/api/verifieddenoms"
 
 	"github.com/emerishq/demeris-api-server/api/account"
+	"github.com/emerishq/demeris-api-server/api/apiutils"
 	"github.com/emerishq/demeris-api-server/api/database"
 	"github.com/emerishq/emeris-utils/store"
 	ginzap "github.com/gin-contrib/zap"
@@ -50,16 +54,20 @@ func New(
 	genericInformer informers.GenericInformer,
 	sdkServiceClients sdkservice.SDKServiceClients,
 	app *usecase.App,
-	debug bool,
+	cfg *config.Config,
 ) *Router {
 	gin.SetMode(gin.ReleaseMode)
 
-	if debug {
+	if cfg.Debug {
 		gin.SetMode(gin.DebugMode)
 	}
 
 	engine := gin.New()
 
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		l.Panicw("cannot set trusted proxies", "error", err)
+	}
+
 	engine.Use(logging.AddLoggerMiddleware(l))
 	r := &Router{
 		g:  engine,
@@ -72,7 +80,7 @@ func New(
 
 	validation.JSONFields(binding.Validator)
 
-	if debug {
+	if cfg.Debug {
 		engine.Use(logging.LogRequest(l.Desugar()))
 	}
 
@@ -84,7 +92,7 @@ func New(
 
 	relayersInformer := relayer.NewInformer(genericInformer, kubeNamespace)
 
-	registerRoutes(engine, r.DB, r.s, relayersInformer, sdkServiceClients, app)
+	registerRoutes(engine, r.DB, r.s, relayersInformer, sdkServiceClients, app, cfg)
 
 	return r
 }
@@ -133,10 +141,16 @@ func tryGetIntCorrelationID(c *gin.Context) string {
 
 func registerRoutes(engine *gin.Engine, db *database.Database, s *store.Store,
 	relayersInformer *relayer.Informer, sdkServiceClients sdkservice.SDKServiceClients,
-	app *usecase.App) {
+	app *usecase.App, cfg *config.Config) {
+	sequences := apiutils.NewSequences(s, cfg.SequenceReservationTTL)
+
+	// the allowlist is validated along with cfg
+	allowlist, _ := ratelimit.ParseAllowlist(cfg.RateLimitAllowlist)
+	limiter := ratelimit.New(s, allowlist)
+
 	// @tag.name Account
 	// @tag.description Account-querying endpoints
-	account.Register(engine, db, s, sdkServiceClients)
+	account.Register(engine, db, s, sdkServiceClients, sequences, limiter, ratelimit.Limit{Rate: cfg.TxRateLimit, Burst: cfg.TxRateBurst})
 
 	// @tag.name Denoms
 	// @tag.description Denoms-related endpoints
@@ -146,9 +160,16 @@ func registerRoutes(engine *gin.Engine, db *database.Database, s *store.Store,
 	// @tag.description Chain-related endpoints
 	chains.Register(engine, db, stringcache.NewStoreBackend(s), sdkServiceClients, app)
 
+	// @tag.name IBC
+	// @tag.description IBC-related endpoints
+	ibc.Register(engine, db)
+
+	// endpoints are validated along with cfg
+	tendermintRPCEndpoints, _ := cfg.TendermintRPCEndpointsByChain()
+
 	// @tag.name Transactions
 	// @tag.description Transaction-related endpoints
-	tx.Register(engine, db, s, sdkServiceClients)
+	tx.Register(engine, db, s, sdkServiceClients, sequences, tx.NewHTTPTendermintRPC(tendermintRPCEndpoints, cfg.TendermintRPCURLFormat), limiter, cfg)
 
 	// @tag.name Relayer
 	// @tag.description Relayer-related endpoints
@@ -156,7 +177,7 @@ func registerRoutes(engine *gin.Engine, db *database.Database, s *store.Store,
 
 	// @tag.name Block
 	// @tag.description Blocks-related endpoints
-	block.Register(engine, db, s)
+	block.Register(engine, db, s, sdkServiceClients)
 
 	// @tag.name liquidity
 	// @tag.description pool-related endpoints
@@ -214,6 +214,9 @@ func Setup(runServer bool) *TestingCtx {
 	dbi, err := apiDb.Init(c)
 	CheckNoError(err, l)
 
+	err = dbi.RunMigrations()
+	CheckNoError(err, l)
+
 	r := &router.Router{DB: dbi}
 
 	if runServer {
@@ -241,7 +244,7 @@ func Setup(runServer bool) *TestingCtx {
 			&informer,
 			clients,
 			nil,
-			c.Debug,
+			c,
 		)
 
 		// --- HTTP server ---
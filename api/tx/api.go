@@ -5,19 +5,34 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/config"
 	"github.com/emerishq/demeris-api-server/api/database"
 	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/lib/ratelimit"
 	"github.com/emerishq/demeris-api-server/sdkservice"
 	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	"github.com/emerishq/emeris-utils/logging"
 	"github.com/emerishq/emeris-utils/store"
 	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-func Register(router *gin.Engine, db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) {
-	router.POST("/tx/:chain", Tx(db, s, sdkServiceClients))
-	router.GET("/tx/:src-chain/:dest-chain/:tx-hash", GetDestTx(db, sdkServiceClients))
-	router.POST("/tx/:chain/simulate", GetTxFeeEstimate(db, sdkServiceClients))
+// txRoute is the rate limited route of broadcast txs, batched or not.
+const txRoute = "tx"
+
+func Register(router *gin.Engine, db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, sequences *apiutils.Sequences, rpc TendermintRPC, limiter *ratelimit.Limiter, cfg *config.Config) {
+	txLimit := ratelimit.Limit{Rate: cfg.TxRateLimit, Burst: cfg.TxRateBurst}
+
+	router.POST("/tx/:chain", limiter.Middleware(txRoute, txLimit), Tx(db, s, sdkServiceClients, sequences))
+	router.POST("/tx/batch", BatchTx(db, s, sdkServiceClients, sequences, limiter, txLimit))
+	router.GET("/tx/ibc/timeout", GetIBCTimeout(db, s, sdkServiceClients, cfg.IBCTimeoutMargin))
+	router.GET("/tx/:src-chain/:dest-chain/:tx-hash", GetDestTx(db, sdkServiceClients, rpc))
+	router.POST("/tx/:chain/simulate", limiter.Middleware("simulate", ratelimit.Limit{Rate: cfg.SimulateRateLimit, Burst: cfg.SimulateRateBurst}), GetTxFeeEstimate(db, sdkServiceClients, cfg.GasAdjustment, prices(cfg.PriceOracleURL)))
+	router.POST("/tx/:chain/decode", DecodeTx(db, sdkServiceClients))
+	router.POST("/tx/:chain/build", BuildTx(db, s, sdkServiceClients, cfg.IBCTimeoutMargin))
 	router.GET("/tx/ticket/:chain/:ticket", GetTicket(db, s))
 }
 
@@ -26,20 +41,42 @@ func Register(router *gin.Engine, db *database.Database, s *store.Store, sdkServ
 // @Tags Tx
 // @ID tx
 // @Description Relays a transaction to the relevant chain.
+// @Description The transaction is rejected before being relayed if the chain is offline, if its fee is insufficient
+// @Description or not paid in a fee token of the chain, or if the sequence of one of its signers was already used.
+// @Description A transaction relayed already, or sent again with the same Idempotency-Key header, isn't relayed again:
+// @Description the ticket of the first broadcast is returned instead.
+// @Description The sequence of the signer is held until the ticket expires, so that sequence reservations skip it.
+// @Description In commit mode, the request waits for the transaction to be included in a block, up to timeout or 60s,
+// @Description and returns its result. If it isn't included in time, the ticket is returned with a 202 status.
+// @Description Broadcasts are rate limited per owner, client IP and chain.
 // @Param chainName path string true "chain name"
+// @Param Idempotency-Key header string false "key identifying the broadcast of an owner across retries"
+// @Param mode query string false "broadcast mode, async (default) or commit"
+// @Param timeout query string false "commit mode timeout, such as 30s (default)"
 // @Produce json
-// @Success 200 {object} TxResponse
-// @Failure 500,400 {object} apierrors.UserFacingError
+// @Success 200,202 {object} TxResponse
+// @Header 200,202,429 {integer} X-RateLimit-Remaining "requests left before being rate limited"
+// @Header 429 {integer} Retry-After "seconds until the next request is accepted"
+// @Failure 500,400,429 {object} apierrors.UserFacingError
 // @Router /tx/{chainName} [post]
-func Tx(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+func Tx(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, sequences *apiutils.Sequences) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
 		// var tx typestx.Tx
 		var txRequest TxRequest
 
 		chainName := c.Param("chain")
 
-		err := c.BindJSON(&txRequest)
+		commit, commitTimeout, err := broadcastMode(c.Query("mode"), c.Query("timeout"))
+		if err != nil {
+			e := apierrors.New("tx", err.Error(), http.StatusBadRequest)
+			_ = c.Error(e)
+
+			return
+		}
+
+		err = c.BindJSON(&txRequest)
 
 		if err != nil {
 			e := apierrors.New("tx", fmt.Sprintf("failed to parse JSON"), http.StatusBadRequest).WithLogContext(
@@ -50,41 +87,64 @@ func Tx(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKS
 			return
 		}
 
-		chain, err := db.Chain(ctx, chainName)
-		if err != nil {
-			e := apierrors.New(
-				"chains",
-				fmt.Sprintf("cannot retrieve chain with name %v", chainName),
-				http.StatusBadRequest,
-			).WithLogContext(
-				fmt.Errorf("cannot retrieve chain: %w", err),
-				"name",
-				chainName,
-			)
+		chain, client, e := chainClient(ctx, db, sdkServiceClients, chainName)
+		if e != nil {
 			_ = c.Error(e)
-
 			return
 		}
 
-		client, e := sdkServiceClients.GetSDKServiceClient(chain.MajorSDKVersion())
+		txhash, e := broadcaster{
+			db:                db,
+			s:                 s,
+			sdkServiceClients: sdkServiceClients,
+			sequences:         sequences,
+			logger:            logger,
+		}.broadcast(ctx, chain, client, txRequest.TxBytes, txRequest.Owner, c.GetHeader(idempotencyKeyHeader))
 		if e != nil {
 			_ = c.Error(e)
 			return
 		}
 
-		txhash, err := relayTx(c.Request.Context(), client, s, txRequest.TxBytes, chainName, txRequest.Owner)
+		if !commit {
+			c.JSON(http.StatusOK, TxResponse{
+				Ticket: txhash,
+			})
 
-		if err != nil {
-			e := apierrors.New("tx", fmt.Sprintf("relaying tx failed, %v", err), http.StatusBadRequest).WithLogContext(
-				fmt.Errorf("relaying tx failed: %w", err),
-			)
-			_ = c.Error(e)
+			return
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, commitTimeout)
+		defer cancel()
+
+		res := commitWaiter{
+			ticket: func() (store.Ticket, error) {
+				return s.Get(store.GetKey(chainName, txhash))
+			},
+			queryTx: func(ctx context.Context) ([]byte, error) {
+				return client.QueryTx(ctx, &sdkutilities.QueryTxPayload{
+					ChainName: chainName,
+					Hash:      txhash,
+				})
+			},
+			interval: commitPollInterval,
+		}.wait(waitCtx)
+
+		if res == nil {
+			c.JSON(http.StatusAccepted, TxResponse{
+				Ticket:   txhash,
+				TimedOut: true,
+			})
 
 			return
 		}
 
+		if err := db.SetTxJournalResult(ctx, chainName, txhash, journalResult(res)); err != nil {
+			logger.Errorw("cannot journal tx result", "chain", chainName, "hash", txhash, "error", err)
+		}
+
 		c.JSON(http.StatusOK, TxResponse{
 			Ticket: txhash,
+			Result: res,
 		})
 	}
 }
@@ -92,6 +152,7 @@ func Tx(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKS
 // relayTx relays the tx to the specifc endpoint
 // relayTx will also perform the ticketing mechanism
 // Always expect broadcast mode to be `async`
+// The hash of a relayed tx is returned even if its ticket can't be created.
 func relayTx(ctx context.Context, services sdkutilities.Service, store *store.Store, txBytes []byte, chainName string, owner string) (string, error) {
 	res, err := services.BroadcastTx(ctx, &sdkutilities.BroadcastTxPayload{
 		ChainName: chainName,
@@ -124,6 +185,8 @@ func relayTx(ctx context.Context, services sdkutilities.Service, store *store.St
 // @Router /tx/ticket/{chainName}/{ticketId} [get]
 func GetTicket(db *database.Database, s *store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
 
 		chainName := c.Param("chain")
 		ticketId := c.Param("ticket")
@@ -145,6 +208,12 @@ func GetTicket(db *database.Database, s *store.Store) gin.HandlerFunc {
 			return
 		}
 
+		if res, ok := apiutils.TicketJournalResult(ticket); ok {
+			if err := db.SetTxJournalResult(ctx, chainName, ticketId, res); err != nil {
+				logger.Errorw("cannot journal tx result", "chain", chainName, "hash", ticketId, "error", err)
+			}
+		}
+
 		c.JSON(http.StatusOK, ticket)
 	}
 }
@@ -154,12 +223,23 @@ func GetTicket(db *database.Database, s *store.Store) gin.HandlerFunc {
 // @Tags Tx
 // @ID txFees
 // @Description estimate transaction fees for the relevant chain.
+// @Description The gas used by the simulated transaction is multiplied by the gas adjustment to get the gas limit,
+// @Description which is quoted in every fee token of the chain at its low, average and high gas prices.
+// @Description Quotes of tokens with a known price are also converted to fiat.
+// @Description Simulations are rate limited per client IP and chain.
 // @Param chainName path string true "chain name"
 // @Produce json
 // @Success 200 {object} TxFeeEstimateRes
-// @Failure 500,400 {object} apierrors.UserFacingError
-// @Router /tx/fees/{chainName} [post]
-func GetTxFeeEstimate(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+// @Header 200,429 {integer} X-RateLimit-Remaining "requests left before being rate limited"
+// @Header 429 {integer} Retry-After "seconds until the next request is accepted"
+// @Failure 500,400,429 {object} apierrors.UserFacingError
+// @Router /tx/{chainName}/simulate [post]
+func GetTxFeeEstimate(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients, gasAdjustment float64, price priceFunc) gin.HandlerFunc {
+	// gas limits are never quoted below the simulated gas
+	if gasAdjustment < 1 {
+		gasAdjustment = 1
+	}
+
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 		var txRequest TxFeeEstimateReq
@@ -227,10 +307,32 @@ func GetTxFeeEstimate(db *database.Database, sdkServiceClients sdkservice.SDKSer
 			})
 		}
 
+		gasLimit := adjustedGas(sdkRes.GasUsed, gasAdjustment)
+
+		quotes, err := feeQuotes(chain, gasLimit, price)
+		if err != nil {
+			e := apierrors.New(
+				"tx",
+				fmt.Sprintf("cannot quote fees on chain %v", chainName),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot quote fees: %w", err),
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
 		c.JSON(http.StatusOK, TxFeeEstimateRes{
-			GasWanted: sdkRes.GasWanted,
-			GasUsed:   sdkRes.GasUsed,
-			Fees:      coins,
+			GasWanted:     sdkRes.GasWanted,
+			GasUsed:       sdkRes.GasUsed,
+			Fees:          coins,
+			GasAdjustment: gasAdjustment,
+			GasLimit:      gasLimit,
+			FiatCurrency:  fiatCurrency,
+			Quotes:        quotes,
 		})
 	}
 }
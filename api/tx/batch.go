@@ -0,0 +1,203 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/lib/ratelimit"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+)
+
+// maxBatchTxs is the maximum number of txs broadcast by a single batch.
+const maxBatchTxs = 20
+
+// broadcastFunc broadcasts a tx of a batch and returns its ticket.
+type broadcastFunc func(entry BatchTxEntry) (string, *apierrors.Error)
+
+// batchResult is the outcome of broadcasting a tx of a batch.
+type batchResult struct {
+	ticket string
+	err    *apierrors.Error
+}
+
+// BatchTx relays several transactions, possibly to different chains.
+// @Summary Relays a batch of transactions.
+// @Tags Tx
+// @ID txBatch
+// @Description Relays up to 20 transactions concurrently, each to its own chain, as done by the tx endpoint in
+// @Description async mode. The ticket or the error of every transaction is returned, in the order of the request.
+// @Description In ordered mode, the transactions of each chain are relayed one after the other, in the order of the
+// @Description request, and the ones following a failed transaction on its chain aren't relayed.
+// @Description Each transaction counts against the rate limit of the tx endpoint on its chain, for its owner and
+// @Description the client IP. The ones over the limit aren't relayed and get a 429 error.
+// @Param txs body BatchTxRequest true "transactions to relay"
+// @Produce json
+// @Success 200 {object} BatchTxResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /tx/batch [post]
+func BatchTx(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, sequences *apiutils.Sequences, limiter *ratelimit.Limiter, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		var req BatchTxRequest
+
+		if err := c.BindJSON(&req); err != nil {
+			e := apierrors.New("tx", fmt.Sprintf("failed to parse JSON"), http.StatusBadRequest).WithLogContext(
+				fmt.Errorf("Failed to parse JSON: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		if len(req.Txs) == 0 || len(req.Txs) > maxBatchTxs {
+			e := apierrors.New("tx", fmt.Sprintf("a batch holds 1 to %d txs, got %d", maxBatchTxs, len(req.Txs)), http.StatusBadRequest)
+			_ = c.Error(e)
+
+			return
+		}
+
+		b := broadcaster{
+			db:                db,
+			s:                 s,
+			sdkServiceClients: sdkServiceClients,
+			sequences:         sequences,
+			logger:            logger,
+		}
+
+		ip := c.ClientIP()
+
+		results := broadcastBatch(req.Txs, req.Ordered, func(entry BatchTxEntry) (string, *apierrors.Error) {
+			if e := limitBatchEntry(ctx, logger, limiter, limit, ip, entry); e != nil {
+				return "", e
+			}
+
+			chain, client, e := chainClient(ctx, db, sdkServiceClients, entry.Chain)
+			if e != nil {
+				return "", e
+			}
+
+			return b.broadcast(ctx, chain, client, entry.TxBytes, entry.Owner, "")
+		})
+
+		id, _ := ctx.Value(logging.IntCorrelationIDName).(string)
+
+		res := BatchTxResponse{
+			Results: make([]BatchTxResult, len(results)),
+		}
+
+		for i, r := range results {
+			res.Results[i] = BatchTxResult{
+				Chain:  req.Txs[i].Chain,
+				Ticket: r.ticket,
+			}
+
+			if r.err != nil {
+				logger.Errorw(r.err.Error(), append(r.err.LogKeysAndValues, "error", r.err, "batch_index", i)...)
+
+				userError := apierrors.NewUserFacingError(id, r.err)
+				res.Results[i].Error = &userError
+			}
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// limitBatchEntry takes a token from the tx buckets of entry, sent by the
+// client ip, and returns an error if any of them is empty.
+// The entry goes through if the buckets can't be taken from.
+func limitBatchEntry(ctx context.Context, logger *zap.SugaredLogger, limiter *ratelimit.Limiter, limit ratelimit.Limit, ip string, entry BatchTxEntry) *apierrors.Error {
+	if !limit.Enabled() || limiter.Exempt(ip) {
+		return nil
+	}
+
+	res, err := limiter.TakeClient(ctx, txRoute, entry.Chain, ip, entry.Owner, limit)
+	if err != nil {
+		logger.Errorw("cannot rate limit batch tx", "chain", entry.Chain, "error", err)
+		return nil
+	}
+
+	if !res.Allowed {
+		return ratelimit.TooManyRequests(res).WithLogContext(
+			fmt.Errorf("rate limit of %s exceeded", txRoute),
+			"ip",
+			ip,
+			"owner",
+			entry.Owner,
+		)
+	}
+
+	return nil
+}
+
+// broadcastBatch broadcasts entries concurrently with broadcast, and returns
+// their results in the same order.
+// If ordered is set, the entries of each chain are broadcast one after the
+// other instead, and stop at the first failure.
+func broadcastBatch(entries []BatchTxEntry, ordered bool, broadcast broadcastFunc) []batchResult {
+	results := make([]batchResult, len(entries))
+
+	// indexes of the entries to broadcast in a row
+	var sequences [][]int
+	if ordered {
+		byChain := map[string]int{}
+		for i, entry := range entries {
+			j, ok := byChain[entry.Chain]
+			if !ok {
+				j = len(sequences)
+				byChain[entry.Chain] = j
+				sequences = append(sequences, nil)
+			}
+
+			sequences[j] = append(sequences[j], i)
+		}
+	} else {
+		for i := range entries {
+			sequences = append(sequences, []int{i})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, seq := range sequences {
+		wg.Add(1)
+
+		go func(seq []int) {
+			defer wg.Done()
+
+			var failed *int
+			for _, i := range seq {
+				if failed != nil {
+					results[i].err = apierrors.New(
+						"tx",
+						fmt.Sprintf("not relayed, tx %d of the batch on chain %s failed", *failed, entries[i].Chain),
+						http.StatusFailedDependency,
+					)
+
+					continue
+				}
+
+				results[i].ticket, results[i].err = broadcast(entries[i])
+				if results[i].err != nil {
+					failedIdx := i
+					failed = &failedIdx
+				}
+			}
+		}(seq)
+	}
+
+	wg.Wait()
+
+	return results
+}
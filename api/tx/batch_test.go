@@ -0,0 +1,136 @@
+package tx
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ratelimit"
+)
+
+func Test_broadcastBatch(t *testing.T) {
+	entries := []BatchTxEntry{
+		{Chain: "cosmos-hub", TxBytes: []byte("claim")},
+		{Chain: "osmosis", TxBytes: []byte("claim")},
+		{Chain: "cosmos-hub", TxBytes: []byte("fail")},
+		{Chain: "osmosis", TxBytes: []byte("restake")},
+		{Chain: "cosmos-hub", TxBytes: []byte("restake")},
+	}
+
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+
+	broadcast := func(entry BatchTxEntry) (string, *apierrors.Error) {
+		mu.Lock()
+		sent = append(sent, entry.Chain+"/"+string(entry.TxBytes))
+		mu.Unlock()
+
+		if string(entry.TxBytes) == "fail" {
+			return "", apierrors.New("tx", "insufficient fee", http.StatusBadRequest)
+		}
+
+		return entry.Chain + "/" + string(entry.TxBytes), nil
+	}
+
+	t.Run("unordered", func(t *testing.T) {
+		sent = nil
+		results := broadcastBatch(entries, false, broadcast)
+
+		require.Len(t, sent, 5)
+		require.Equal(t, []batchResult{
+			{ticket: "cosmos-hub/claim"},
+			{ticket: "osmosis/claim"},
+			{err: apierrors.New("tx", "insufficient fee", http.StatusBadRequest)},
+			{ticket: "osmosis/restake"},
+			{ticket: "cosmos-hub/restake"},
+		}, results)
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		sent = nil
+		results := broadcastBatch(entries, true, broadcast)
+
+		// txs following the failed one on its chain aren't sent
+		require.ElementsMatch(t, []string{"cosmos-hub/claim", "cosmos-hub/fail", "osmosis/claim", "osmosis/restake"}, sent)
+		require.Equal(t, "cosmos-hub/claim", results[0].ticket)
+		require.Equal(t, "osmosis/claim", results[1].ticket)
+		require.Equal(t, "insufficient fee", results[2].err.Cause)
+		require.Equal(t, "osmosis/restake", results[3].ticket)
+		require.Empty(t, results[4].ticket)
+		require.Equal(t, http.StatusFailedDependency, results[4].err.StatusCode)
+		require.Contains(t, results[4].err.Cause, "tx 2 of the batch on chain cosmos-hub failed")
+	})
+}
+
+func Test_broadcastBatch_order(t *testing.T) {
+	entries := []BatchTxEntry{
+		{Chain: "cosmos-hub", TxBytes: []byte("1")},
+		{Chain: "cosmos-hub", TxBytes: []byte("2")},
+		{Chain: "cosmos-hub", TxBytes: []byte("3")},
+		{Chain: "osmosis", TxBytes: []byte("1")},
+	}
+
+	var (
+		mu      sync.Mutex
+		hubSent []string
+	)
+
+	// later txs of a chain would overtake earlier ones if they were sent
+	// concurrently
+	broadcast := func(entry BatchTxEntry) (string, *apierrors.Error) {
+		if entry.Chain == "cosmos-hub" {
+			time.Sleep(time.Duration('4'-entry.TxBytes[0]) * time.Millisecond)
+
+			mu.Lock()
+			hubSent = append(hubSent, string(entry.TxBytes))
+			mu.Unlock()
+		}
+
+		return string(entry.TxBytes), nil
+	}
+
+	results := broadcastBatch(entries, true, broadcast)
+	require.Equal(t, []string{"1", "2", "3"}, hubSent)
+	require.Len(t, results, 4)
+	for _, r := range results {
+		require.Nil(t, r.err)
+	}
+}
+
+func Test_limitBatchEntry(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+	s, _ := newTestStore(t)
+
+	allowlist, err := ratelimit.ParseAllowlist([]string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	limiter := ratelimit.New(s, allowlist)
+	limit := ratelimit.Limit{Rate: 0.001, Burst: 2}
+
+	alice := BatchTxEntry{Chain: "cosmos-hub", Owner: "alice"}
+	require.Nil(t, limitBatchEntry(ctx, logger, limiter, limit, "192.0.2.1", alice))
+	require.Nil(t, limitBatchEntry(ctx, logger, limiter, limit, "192.0.2.1", alice))
+
+	// each entry takes a token
+	e := limitBatchEntry(ctx, logger, limiter, limit, "192.0.2.1", alice)
+	require.NotNil(t, e)
+	require.Equal(t, http.StatusTooManyRequests, e.StatusCode)
+
+	// from the buckets of its own chain and owner
+	require.Nil(t, limitBatchEntry(ctx, logger, limiter, limit, "192.0.2.1", BatchTxEntry{Chain: "osmosis", Owner: "alice"}))
+	require.NotNil(t, limitBatchEntry(ctx, logger, limiter, limit, "192.0.2.1", BatchTxEntry{Chain: "cosmos-hub", Owner: "bob"}))
+	require.NotNil(t, limitBatchEntry(ctx, logger, limiter, limit, "192.0.2.2", alice))
+
+	// allowlisted clients and disabled limits aren't limited
+	require.Nil(t, limitBatchEntry(ctx, logger, limiter, limit, "10.0.0.1", alice))
+	require.Nil(t, limitBatchEntry(ctx, logger, limiter, ratelimit.Limit{}, "192.0.2.1", alice))
+}
@@ -0,0 +1,186 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/store"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+)
+
+// chainClient returns chainName and the sdk-service client of its SDK
+// version.
+func chainClient(ctx context.Context, db *database.Database, sdkServiceClients sdkservice.SDKServiceClients, chainName string) (cns.Chain, sdkutilities.Service, *apierrors.Error) {
+	chain, err := db.Chain(ctx, chainName)
+	if err != nil {
+		return cns.Chain{}, nil, apierrors.New(
+			"chains",
+			fmt.Sprintf("cannot retrieve chain with name %v", chainName),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain: %w", err),
+			"name",
+			chainName,
+		)
+	}
+
+	client, err := sdkServiceClients.GetSDKServiceClient(chain.MajorSDKVersion())
+	if err != nil {
+		return cns.Chain{}, nil, apierrors.Wrap(
+			err,
+			"chains",
+			fmt.Sprintf("cannot retrieve sdk-service for chain %v", chainName),
+			http.StatusBadRequest,
+		)
+	}
+
+	return chain, client, nil
+}
+
+// broadcaster relays txs to the node of their chain.
+type broadcaster struct {
+	db                *database.Database
+	s                 *store.Store
+	sdkServiceClients sdkservice.SDKServiceClients
+	sequences         *apiutils.Sequences
+	logger            *zap.SugaredLogger
+}
+
+// broadcast validates txBytes and relays it through client on chain for
+// owner, then returns the hash of the tx, which is also its ticket.
+// A tx relayed already, or whose idempotencyKey was claimed by a previous
+// tx, isn't relayed again: the hash of the first tx is returned instead.
+// The returned error is meant to be handed over to the user as is. If the tx
+// was relayed but its ticket couldn't be created, its hash is returned along
+// with the error.
+func (b broadcaster) broadcast(
+	ctx context.Context,
+	chain cns.Chain,
+	client sdkutilities.Service,
+	txBytes []byte,
+	owner string,
+	idempotencyKey string,
+) (string, *apierrors.Error) {
+	chainName := chain.ChainName
+
+	txhash := txHash(txBytes)
+	duplicate := false
+
+	if idempotencyKey != "" {
+		claimedHash, err := claimIdempotencyKey(ctx, b.s, chainName, owner, idempotencyKey, txhash)
+		if err != nil {
+			return "", apierrors.New(
+				"tx",
+				fmt.Sprintf("cannot check idempotency key"),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot claim idempotency key: %w", err),
+				"name",
+				chainName,
+			)
+		}
+
+		if claimedHash != txhash {
+			txhash = claimedHash
+			duplicate = true
+		}
+	}
+
+	// a retried tx is returned its live ticket instead of being relayed again
+	if !duplicate && b.s.Exists(store.GetKey(chainName, txhash)) {
+		duplicate = true
+	}
+
+	if duplicate {
+		b.logger.Debugw(
+			"tx already relayed",
+			"chain", chainName,
+			"hash", txhash,
+			"idempotency_key", idempotencyKey,
+		)
+
+		return txhash, nil
+	}
+
+	release := func() {
+		if idempotencyKey == "" {
+			return
+		}
+
+		if err := releaseIdempotencyKey(ctx, b.s, chainName, owner, idempotencyKey); err != nil {
+			b.logger.Errorw("cannot release idempotency key", "chain", chainName, "error", err)
+		}
+	}
+
+	meta, e := validateTx(ctx, b.db, client, b.sdkServiceClients, chain, txBytes)
+	if e != nil {
+		release()
+		return "", e
+	}
+
+	b.logger.Debugw(
+		"relaying tx",
+		"chain", chainName,
+		"type", meta.TxType,
+		"signer", meta.Signer,
+		"sequence", meta.SignerSequence,
+		"fee_payer", meta.FeePayer,
+	)
+
+	submittedAt := time.Now()
+	txhash, err := relayTx(ctx, client, b.s, txBytes, chainName, owner)
+
+	signer, sequence, tracked := signerSequence(meta)
+
+	if err != nil && txhash == "" {
+		release()
+
+		// the tx didn't make it to the node, its sequence is free again
+		if tracked {
+			if err := b.sequences.Release(ctx, chainName, signer, sequence); err != nil {
+				b.logger.Errorw("cannot release sequence", "chain", chainName, "signer", meta.Signer, "error", err)
+			}
+		}
+
+		return "", apierrors.New("tx", fmt.Sprintf("relaying tx failed, %v", err), http.StatusBadRequest).WithLogContext(
+			fmt.Errorf("relaying tx failed: %w", err),
+		)
+	}
+
+	// the tx is relayed already, failing to journal it isn't reported
+	if err := b.db.InsertTxJournal(ctx, journalEntry(chainName, txhash, owner, meta, submittedAt)); err != nil {
+		b.logger.Errorw("cannot journal tx", "chain", chainName, "hash", txhash, "error", err)
+	}
+
+	if tracked {
+		if err := b.sequences.Hold(ctx, chainName, signer, sequence, txhash); err != nil {
+			b.logger.Errorw("cannot hold sequence", "chain", chainName, "hash", txhash, "error", err)
+		}
+	}
+
+	// the tx is in the mempool, but its ticket is missing
+	if err != nil {
+		return txhash, apierrors.New(
+			"tx",
+			fmt.Sprintf("tx %s relayed, but its ticket cannot be created", txhash),
+			http.StatusInternalServerError,
+		).WithLogContext(
+			fmt.Errorf("cannot create ticket: %w", err),
+			"name",
+			chainName,
+			"hash",
+			txhash,
+		)
+	}
+
+	return txhash, nil
+}
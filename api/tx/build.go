@@ -0,0 +1,645 @@
+package tx
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec/legacy"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/cosmos/cosmos-sdk/x/auth/legacy/legacytx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/emerishq/emeris-utils/store"
+)
+
+// Message types accepted by BuildTx.
+const (
+	BuildMsgSend            = "send"
+	BuildMsgTransfer        = "transfer"
+	BuildMsgDelegate        = "delegate"
+	BuildMsgUndelegate      = "undelegate"
+	BuildMsgRedelegate      = "redelegate"
+	BuildMsgWithdrawRewards = "withdraw_rewards"
+)
+
+// Gas price levels, as set in cns.GasPrice.
+const (
+	gasPriceLow     = "low"
+	gasPriceAverage = "average"
+	gasPriceHigh    = "high"
+)
+
+// msgGas is the gas a message of each type is expected to use, margin
+// included.
+var msgGas = map[string]uint64{
+	BuildMsgSend:            100000,
+	BuildMsgTransfer:        150000,
+	BuildMsgDelegate:        250000,
+	BuildMsgUndelegate:      300000,
+	BuildMsgRedelegate:      350000,
+	BuildMsgWithdrawRewards: 150000,
+}
+
+const (
+	ibcTransferPort      = "transfer"
+	ibcTransferAminoType = "cosmos-sdk/MsgTransfer"
+)
+
+// transferRoute is how tokens reach a destination chain over IBC.
+type transferRoute struct {
+	channel        string
+	receiverPrefix string
+	timeout        IBCTimeoutResponse
+}
+
+// BuildTx builds an unsigned transaction.
+// @Summary Builds an unsigned transaction.
+// @Tags Tx
+// @ID buildTx
+// @Description Builds an unsigned transaction made of send, transfer, delegate, undelegate, redelegate and
+// @Description withdraw_rewards messages, all signed by signer.
+// @Description The account number and sequence of signer, the chain ID, the fee and the timeout of IBC transfers are
+// @Description filled in. Transfers go through the primary channel to their destination chain.
+// @Description The transaction is returned as a SIGN_MODE_DIRECT sign doc and as an amino JSON sign doc, each
+// @Description along with the body and auth info bytes to broadcast once signed.
+// @Param chainName path string true "chain name"
+// @Param tx body BuildTxRequest true "transaction to build"
+// @Produce json
+// @Success 200 {object} BuildTxResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /tx/{chainName}/build [post]
+func BuildTx(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, ibcTimeoutMargin time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		var req BuildTxRequest
+
+		chainName := c.Param("chain")
+
+		if err := c.BindJSON(&req); err != nil {
+			e := apierrors.New("tx", fmt.Sprintf("failed to parse JSON"), http.StatusBadRequest).WithLogContext(
+				fmt.Errorf("Failed to parse JSON: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		chain, err := db.Chain(ctx, chainName)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve chain with name %v", chainName),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chain: %w", err),
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		signer, err := decodeAddress(req.Signer, chain.NodeInfo.Bech32Config.Bech32PrefixAccAddr())
+		if err != nil {
+			e := apierrors.New("tx", fmt.Sprintf("invalid signer, %v", err), http.StatusBadRequest)
+			_ = c.Error(e)
+
+			return
+		}
+
+		routes := map[string]transferRoute{}
+		for _, m := range req.Messages {
+			if m.Type != BuildMsgTransfer {
+				continue
+			}
+
+			if _, ok := routes[m.DestChain]; ok {
+				continue
+			}
+
+			route, e := transferRouteTo(ctx, logger, db, s, sdkServiceClients, chain, m.DestChain, ibcTimeoutMargin)
+			if e != nil {
+				_ = c.Error(e)
+				return
+			}
+
+			routes[m.DestChain] = route
+		}
+
+		account, err := apiutils.FetchAccountNumbers(ctx, chain, hex.EncodeToString(signer), sdkServiceClients)
+		if err != nil {
+			e := apierrors.New(
+				"tx",
+				fmt.Sprintf("cannot retrieve account of signer %v", req.Signer),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot fetch account numbers: %w", err),
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		res, err := buildTx(chain, account, req, routes)
+		if err != nil {
+			e := apierrors.New("tx", fmt.Sprintf("cannot build tx, %v", err), http.StatusBadRequest)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// transferRouteTo returns the route of transfers from chain to destChain.
+// The returned error is meant to be handed over to the user as is.
+func transferRouteTo(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	db *database.Database,
+	s *store.Store,
+	sdkServiceClients sdkservice.SDKServiceClients,
+	chain cns.Chain,
+	destChain string,
+	margin time.Duration,
+) (transferRoute, *apierrors.Error) {
+	channel, ok := chain.PrimaryChannel[destChain]
+	if !ok || channel == "" {
+		return transferRoute{}, apierrors.New(
+			"tx",
+			fmt.Sprintf("no primary channel from chain %v to chain %v", chain.ChainName, destChain),
+			http.StatusBadRequest,
+		)
+	}
+
+	destChainInfo, err := db.Chain(ctx, destChain)
+	if err != nil {
+		return transferRoute{}, apierrors.New(
+			"chains",
+			fmt.Sprintf("cannot retrieve chain with name %v", destChain),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain: %w", err),
+			"name",
+			destChain,
+		)
+	}
+
+	timeout, e := ibcTimeout(ctx, logger, db, s, sdkServiceClients, chain.ChainName, destChain, margin)
+	if e != nil {
+		return transferRoute{}, e
+	}
+
+	return transferRoute{
+		channel:        channel,
+		receiverPrefix: destChainInfo.NodeInfo.Bech32Config.Bech32PrefixAccAddr(),
+		timeout:        timeout,
+	}, nil
+}
+
+// buildTx builds the unsigned tx req on chain, for the signer account.
+// routes holds the route of the transfers of req, indexed by destination
+// chain.
+func buildTx(chain cns.Chain, account tracelistener.AuthRow, req BuildTxRequest, routes map[string]transferRoute) (BuildTxResponse, error) {
+	if len(req.Messages) == 0 {
+		return BuildTxResponse{}, fmt.Errorf("no message")
+	}
+
+	var (
+		msgs            []*codectypes.Any
+		aminoMsgs       []json.RawMessage
+		gas             uint64
+		transferTimeout *IBCTimeoutResponse
+	)
+
+	for i, m := range req.Messages {
+		var (
+			msgAny *codectypes.Any
+			amino  json.RawMessage
+			err    error
+		)
+
+		if m.Type == BuildMsgTransfer {
+			route, ok := routes[m.DestChain]
+			if !ok {
+				return BuildTxResponse{}, fmt.Errorf("message %d: no route to chain %q", i, m.DestChain)
+			}
+
+			msgAny, amino, err = transferMsg(req.Signer, m, route)
+			timeout := route.timeout
+			transferTimeout = &timeout
+		} else {
+			var msg legacytx.LegacyMsg
+			if msg, err = sdkMsg(chain, req.Signer, m); err == nil {
+				if msgAny, err = codectypes.NewAnyWithValue(msg); err == nil {
+					amino = msg.GetSignBytes()
+				}
+			}
+		}
+
+		if err != nil {
+			return BuildTxResponse{}, fmt.Errorf("message %d: %w", i, err)
+		}
+
+		msgs = append(msgs, msgAny)
+		aminoMsgs = append(aminoMsgs, amino)
+		gas += msgGas[m.Type]
+	}
+
+	if req.Gas != 0 {
+		gas = req.Gas
+	}
+
+	fee, err := buildFee(chain, req.FeeDenom, req.GasPriceLevel, gas)
+	if err != nil {
+		return BuildTxResponse{}, err
+	}
+
+	var pubKey *codectypes.Any
+	if len(req.PubKey) > 0 {
+		if pubKey, err = signerPubKey(req.PubKey, req.Signer, chain.NodeInfo.Bech32Config.Bech32PrefixAccAddr()); err != nil {
+			return BuildTxResponse{}, err
+		}
+	}
+
+	bodyBytes, err := (&sdktx.TxBody{
+		Messages: msgs,
+		Memo:     req.Memo,
+	}).Marshal()
+	if err != nil {
+		return BuildTxResponse{}, fmt.Errorf("cannot encode tx body: %w", err)
+	}
+
+	directAuthInfo, err := authInfoBytes(pubKey, signing.SignMode_SIGN_MODE_DIRECT, account.SequenceNumber, fee)
+	if err != nil {
+		return BuildTxResponse{}, err
+	}
+
+	aminoAuthInfo, err := authInfoBytes(pubKey, signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON, account.SequenceNumber, fee)
+	if err != nil {
+		return BuildTxResponse{}, err
+	}
+
+	chainID := chain.NodeInfo.ChainID
+
+	signBytes, err := (&sdktx.SignDoc{
+		BodyBytes:     bodyBytes,
+		AuthInfoBytes: directAuthInfo,
+		ChainId:       chainID,
+		AccountNumber: account.AccountNumber,
+	}).Marshal()
+	if err != nil {
+		return BuildTxResponse{}, fmt.Errorf("cannot encode sign doc: %w", err)
+	}
+
+	aminoSignDoc, err := legacy.Cdc.MarshalJSON(legacytx.StdSignDoc{
+		AccountNumber: account.AccountNumber,
+		Sequence:      account.SequenceNumber,
+		ChainID:       chainID,
+		Memo:          req.Memo,
+		Fee:           json.RawMessage(fee.Bytes()),
+		Msgs:          aminoMsgs,
+	})
+	if err != nil {
+		return BuildTxResponse{}, fmt.Errorf("cannot encode amino sign doc: %w", err)
+	}
+
+	return BuildTxResponse{
+		ChainID:       chainID,
+		AccountNumber: account.AccountNumber,
+		Sequence:      account.SequenceNumber,
+		Fee:           fee.Amount,
+		Gas:           fee.Gas,
+		Memo:          req.Memo,
+		IBCTimeout:    transferTimeout,
+		Direct: DirectSignDoc{
+			BodyBytes:     bodyBytes,
+			AuthInfoBytes: directAuthInfo,
+			SignBytes:     signBytes,
+		},
+		Amino: AminoSignDoc{
+			BodyBytes:     bodyBytes,
+			AuthInfoBytes: aminoAuthInfo,
+			SignDoc:       sdk.MustSortJSON(aminoSignDoc),
+		},
+	}, nil
+}
+
+// sdkMsg returns the Cosmos SDK message m, sent by signer on chain.
+func sdkMsg(chain cns.Chain, signer string, m BuildTxMsg) (legacytx.LegacyMsg, error) {
+	accPrefix := chain.NodeInfo.Bech32Config.Bech32PrefixAccAddr()
+	valPrefix := chain.NodeInfo.Bech32Config.Bech32PrefixValAddr()
+
+	if m.Type == BuildMsgSend {
+		if _, err := decodeAddress(m.ToAddress, accPrefix); err != nil {
+			return nil, fmt.Errorf("invalid to_address, %w", err)
+		}
+
+		if m.Amount.Empty() || m.Amount.Validate() != nil {
+			return nil, fmt.Errorf("invalid amount %s", m.Amount)
+		}
+
+		return &banktypes.MsgSend{
+			FromAddress: signer,
+			ToAddress:   m.ToAddress,
+			Amount:      m.Amount,
+		}, nil
+	}
+
+	if _, ok := msgGas[m.Type]; !ok {
+		return nil, fmt.Errorf("unknown message type %q", m.Type)
+	}
+
+	if _, err := decodeAddress(m.ValidatorAddress, valPrefix); err != nil {
+		return nil, fmt.Errorf("invalid validator_address, %w", err)
+	}
+
+	if m.Type == BuildMsgWithdrawRewards {
+		return &distrtypes.MsgWithdrawDelegatorReward{
+			DelegatorAddress: signer,
+			ValidatorAddress: m.ValidatorAddress,
+		}, nil
+	}
+
+	amount, err := singleCoin(m.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	switch m.Type {
+	case BuildMsgDelegate:
+		return &stakingtypes.MsgDelegate{
+			DelegatorAddress: signer,
+			ValidatorAddress: m.ValidatorAddress,
+			Amount:           amount,
+		}, nil
+	case BuildMsgUndelegate:
+		return &stakingtypes.MsgUndelegate{
+			DelegatorAddress: signer,
+			ValidatorAddress: m.ValidatorAddress,
+			Amount:           amount,
+		}, nil
+	default:
+		if _, err := decodeAddress(m.ValidatorDstAddress, valPrefix); err != nil {
+			return nil, fmt.Errorf("invalid validator_dst_address, %w", err)
+		}
+
+		return &stakingtypes.MsgBeginRedelegate{
+			DelegatorAddress:    signer,
+			ValidatorSrcAddress: m.ValidatorAddress,
+			ValidatorDstAddress: m.ValidatorDstAddress,
+			Amount:              amount,
+		}, nil
+	}
+}
+
+// transferMsg returns the IBC transfer m sent by signer along route, as a
+// protobuf Any and as amino JSON.
+// It's encoded by hand since ibc-go isn't a dependency of the api-server.
+func transferMsg(signer string, m BuildTxMsg, route transferRoute) (*codectypes.Any, json.RawMessage, error) {
+	if _, err := decodeAddress(m.Receiver, route.receiverPrefix); err != nil {
+		return nil, nil, fmt.Errorf("invalid receiver, %w", err)
+	}
+
+	token, err := singleCoin(m.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	height := route.timeout.TimeoutHeight
+	timestamp := route.timeout.TimeoutTimestamp
+
+	// field numbers from ibc.applications.transfer.v1.MsgTransfer, token and
+	// timeout_height aren't nullable so they're always encoded
+	var tokenBytes, heightBytes, value []byte
+	tokenBytes = protoString(tokenBytes, 1, token.Denom)
+	tokenBytes = protoString(tokenBytes, 2, token.Amount.String())
+	heightBytes = protoVarint(heightBytes, 1, height.RevisionNumber)
+	heightBytes = protoVarint(heightBytes, 2, height.RevisionHeight)
+
+	value = protoString(value, 1, ibcTransferPort)
+	value = protoString(value, 2, route.channel)
+	value = protoBytes(value, 3, tokenBytes)
+	value = protoString(value, 4, signer)
+	value = protoString(value, 5, m.Receiver)
+	value = protoBytes(value, 6, heightBytes)
+	value = protoVarint(value, 7, timestamp)
+
+	amino, err := json.Marshal(aminoTransfer{
+		Type: ibcTransferAminoType,
+		Value: aminoTransferValue{
+			SourcePort:       ibcTransferPort,
+			SourceChannel:    route.channel,
+			Token:            token,
+			Sender:           signer,
+			Receiver:         m.Receiver,
+			TimeoutHeight:    aminoHeight{RevisionNumber: height.RevisionNumber, RevisionHeight: height.RevisionHeight},
+			TimeoutTimestamp: timestamp,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &codectypes.Any{
+		TypeUrl: ibcTransferMsgType,
+		Value:   value,
+	}, sdk.MustSortJSON(amino), nil
+}
+
+// aminoTransfer is the amino JSON encoding of MsgTransfer, zero values are
+// omitted.
+type aminoTransfer struct {
+	Type  string             `json:"type"`
+	Value aminoTransferValue `json:"value"`
+}
+
+type aminoTransferValue struct {
+	SourcePort       string      `json:"source_port"`
+	SourceChannel    string      `json:"source_channel"`
+	Token            sdk.Coin    `json:"token"`
+	Sender           string      `json:"sender"`
+	Receiver         string      `json:"receiver"`
+	TimeoutHeight    aminoHeight `json:"timeout_height"`
+	TimeoutTimestamp uint64      `json:"timeout_timestamp,omitempty,string"`
+}
+
+type aminoHeight struct {
+	RevisionNumber uint64 `json:"revision_number,omitempty,string"`
+	RevisionHeight uint64 `json:"revision_height,omitempty,string"`
+}
+
+// buildFee returns the fee paying for gas in denom, or the first fee token
+// of chain if empty, at the gas price level.
+func buildFee(chain cns.Chain, denom, level string, gas uint64) (legacytx.StdFee, error) {
+	feeTokens := chain.FeeTokens()
+	if len(feeTokens) == 0 {
+		return legacytx.StdFee{Gas: gas}, nil
+	}
+
+	feeToken := feeTokens[0]
+	if denom != "" {
+		found := false
+		for _, ft := range feeTokens {
+			if ft.Name == denom {
+				feeToken, found = ft, true
+				break
+			}
+		}
+
+		if !found {
+			return legacytx.StdFee{}, fmt.Errorf("%s cannot be used to pay fees on chain %s", denom, chain.ChainName)
+		}
+	}
+
+	var gasPrice float64
+	switch level {
+	case gasPriceLow:
+		gasPrice = feeToken.GasPriceLevels.Low
+	case "", gasPriceAverage:
+		gasPrice = feeToken.GasPriceLevels.Average
+	case gasPriceHigh:
+		gasPrice = feeToken.GasPriceLevels.High
+	default:
+		return legacytx.StdFee{}, fmt.Errorf("unknown gas price level %q, must be %s, %s or %s", level, gasPriceLow, gasPriceAverage, gasPriceHigh)
+	}
+
+	amount, err := minFee(gasPrice, gas)
+	if err != nil {
+		return legacytx.StdFee{}, fmt.Errorf("cannot compute fee in %s: %w", feeToken.Name, err)
+	}
+
+	fee := legacytx.StdFee{Gas: gas}
+	if !amount.IsZero() {
+		fee.Amount = sdk.NewCoins(sdk.NewCoin(feeToken.Name, sdk.NewIntFromBigInt(amount.BigInt())))
+	}
+
+	return fee, nil
+}
+
+// signerPubKey returns the compressed secp256k1 public key of signer as a
+// protobuf Any, after checking it matches the signer address.
+func signerPubKey(key []byte, signer, prefix string) (*codectypes.Any, error) {
+	if len(key) != secp256k1.PubKeySize {
+		return nil, fmt.Errorf("invalid pub_key, must be a %d bytes compressed secp256k1 public key", secp256k1.PubKeySize)
+	}
+
+	pk := &secp256k1.PubKey{Key: key}
+
+	address, err := bech32.ConvertAndEncode(prefix, pk.Address())
+	if err != nil || address != signer {
+		return nil, fmt.Errorf("pub_key doesn't match signer %s", signer)
+	}
+
+	return codectypes.NewAnyWithValue(pk)
+}
+
+// authInfoBytes returns the encoded auth info of a tx signed with mode.
+func authInfoBytes(pubKey *codectypes.Any, mode signing.SignMode, sequence uint64, fee legacytx.StdFee) ([]byte, error) {
+	bz, err := (&sdktx.AuthInfo{
+		SignerInfos: []*sdktx.SignerInfo{
+			{
+				PublicKey: pubKey,
+				ModeInfo: &sdktx.ModeInfo{
+					Sum: &sdktx.ModeInfo_Single_{Single: &sdktx.ModeInfo_Single{Mode: mode}},
+				},
+				Sequence: sequence,
+			},
+		},
+		Fee: &sdktx.Fee{
+			Amount:   fee.Amount,
+			GasLimit: fee.Gas,
+		},
+	}).Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode tx auth info: %w", err)
+	}
+
+	return bz, nil
+}
+
+// decodeAddress returns the bytes of the bech32 address, after checking it
+// has prefix.
+func decodeAddress(address, prefix string) ([]byte, error) {
+	hrp, bz, err := bech32.DecodeAndConvert(address)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a bech32 address", address)
+	}
+
+	if hrp != prefix {
+		return nil, fmt.Errorf("%q doesn't have the %s prefix", address, prefix)
+	}
+
+	return bz, nil
+}
+
+// singleCoin returns the only coin of coins.
+func singleCoin(coins sdk.Coins) (sdk.Coin, error) {
+	if len(coins) != 1 || coins.Validate() != nil {
+		return sdk.Coin{}, fmt.Errorf("invalid amount %s, must be a single positive coin", coins)
+	}
+
+	return coins[0], nil
+}
+
+// protoVarint appends the varint field to b, omitted if zero.
+func protoVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = appendUvarint(b, uint64(field)<<3)
+
+	return appendUvarint(b, v)
+}
+
+// protoBytes appends the length-delimited field to b.
+func protoBytes(b []byte, field int, v []byte) []byte {
+	b = appendUvarint(b, uint64(field)<<3|2)
+	b = appendUvarint(b, uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// protoString appends the string field to b, omitted if empty.
+func protoString(b []byte, field int, v string) []byte {
+	if v == "" {
+		return b
+	}
+
+	return protoBytes(b, field, []byte(v))
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+
+	return append(b, buf[:n]...)
+}
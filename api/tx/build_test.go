@@ -0,0 +1,267 @@
+package tx
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+var buildChain = cns.Chain{
+	ChainName: "cosmos-hub",
+	NodeInfo: cns.NodeInfo{
+		ChainID: "cosmoshub-4",
+		Bech32Config: cns.Bech32Config{
+			MainPrefix:      "cosmos",
+			PrefixValidator: "val",
+			PrefixOperator:  "oper",
+		},
+	},
+	Denoms: cns.DenomList{
+		{Name: "uatom", FeeToken: true, GasPriceLevels: cns.GasPrice{Low: 0.01, Average: 0.025, High: 0.04}},
+		{Name: "ustake", FeeToken: true, GasPriceLevels: cns.GasPrice{Low: 1, Average: 2, High: 3}},
+	},
+}
+
+func testAddress(t *testing.T, prefix string, b byte) string {
+	t.Helper()
+
+	address, err := bech32.ConvertAndEncode(prefix, []byte{b, b, b, b, b, b, b, b, b, b, b, b, b, b, b, b, b, b, b, b})
+	require.NoError(t, err)
+
+	return address
+}
+
+func coins(t *testing.T, s string) sdk.Coins {
+	t.Helper()
+
+	c, err := sdk.ParseCoinsNormalized(s)
+	require.NoError(t, err)
+
+	return c
+}
+
+// protoFields returns the fields of the protobuf message bz, indexed by field
+// number.
+func protoFields(t *testing.T, bz []byte) map[uint64]interface{} {
+	t.Helper()
+
+	ret := map[uint64]interface{}{}
+	for len(bz) > 0 {
+		key, n := binary.Uvarint(bz)
+		require.Positive(t, n)
+		bz = bz[n:]
+
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(bz)
+			require.Positive(t, n)
+			ret[key>>3] = v
+			bz = bz[n:]
+		case 2:
+			l, n := binary.Uvarint(bz)
+			require.Positive(t, n)
+			ret[key>>3] = bz[n : n+int(l)]
+			bz = bz[n+int(l):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+
+	return ret
+}
+
+func Test_buildTx(t *testing.T) {
+	pk := secp256k1.GenPrivKey().PubKey().(*secp256k1.PubKey)
+	signer, err := bech32.ConvertAndEncode("cosmos", pk.Address())
+	require.NoError(t, err)
+
+	validator := testAddress(t, "cosmosvaloper", 1)
+	otherValidator := testAddress(t, "cosmosvaloper", 2)
+	account := tracelistener.AuthRow{AccountNumber: 42, SequenceNumber: 7}
+
+	req := BuildTxRequest{
+		Signer: signer,
+		PubKey: pk.Key,
+		Memo:   "memo",
+		Messages: []BuildTxMsg{
+			{Type: BuildMsgSend, ToAddress: testAddress(t, "cosmos", 3), Amount: coins(t, "10uatom")},
+			{Type: BuildMsgDelegate, ValidatorAddress: validator, Amount: coins(t, "20uatom")},
+			{Type: BuildMsgUndelegate, ValidatorAddress: validator, Amount: coins(t, "5uatom")},
+			{Type: BuildMsgRedelegate, ValidatorAddress: validator, ValidatorDstAddress: otherValidator, Amount: coins(t, "5uatom")},
+			{Type: BuildMsgWithdrawRewards, ValidatorAddress: validator},
+		},
+	}
+
+	res, err := buildTx(buildChain, account, req, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "cosmoshub-4", res.ChainID)
+	require.Equal(t, uint64(42), res.AccountNumber)
+	require.Equal(t, uint64(7), res.Sequence)
+	require.Equal(t, uint64(1150000), res.Gas)
+	require.Equal(t, "28750uatom", res.Fee.String())
+	require.Nil(t, res.IBCTimeout)
+
+	// the built tx decodes as expected, once signed
+	raw, err := (&sdktx.TxRaw{
+		BodyBytes:     res.Direct.BodyBytes,
+		AuthInfoBytes: res.Direct.AuthInfoBytes,
+		Signatures:    [][]byte{[]byte("signature")},
+	}).Marshal()
+	require.NoError(t, err)
+
+	decoded, err := decodeTxView(raw, "cosmos", nil)
+	require.NoError(t, err)
+	require.Equal(t, "memo", decoded.Memo)
+	require.Equal(t, uint64(1150000), decoded.GasLimit)
+	require.Equal(t, []DecodedSigner{{Address: signer, PubKeyType: secp256k1PubKeyType, Sequence: 7, SignMode: "SIGN_MODE_DIRECT"}}, decoded.Signers)
+	require.Len(t, decoded.Messages, 5)
+	require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", decoded.Messages[0].TypeURL)
+	require.Equal(t, "/cosmos.staking.v1beta1.MsgDelegate", decoded.Messages[1].TypeURL)
+	require.Equal(t, "/cosmos.staking.v1beta1.MsgUndelegate", decoded.Messages[2].TypeURL)
+	require.Equal(t, "/cosmos.staking.v1beta1.MsgBeginRedelegate", decoded.Messages[3].TypeURL)
+	require.Equal(t, "/cosmos.distribution.v1beta1.MsgWithdrawDelegatorReward", decoded.Messages[4].TypeURL)
+
+	var signDoc sdktx.SignDoc
+	require.NoError(t, signDoc.Unmarshal(res.Direct.SignBytes))
+	require.Equal(t, "cosmoshub-4", signDoc.ChainId)
+	require.Equal(t, uint64(42), signDoc.AccountNumber)
+	require.Equal(t, res.Direct.BodyBytes, signDoc.BodyBytes)
+	require.Equal(t, res.Direct.AuthInfoBytes, signDoc.AuthInfoBytes)
+
+	var aminoAuthInfo sdktx.AuthInfo
+	require.NoError(t, aminoAuthInfo.Unmarshal(res.Amino.AuthInfoBytes))
+	require.Equal(t, "SIGN_MODE_LEGACY_AMINO_JSON", aminoAuthInfo.SignerInfos[0].ModeInfo.GetSingle().Mode.String())
+	require.Equal(t, res.Direct.BodyBytes, res.Amino.BodyBytes)
+
+	var aminoDoc struct {
+		AccountNumber string `json:"account_number"`
+		Sequence      string `json:"sequence"`
+		ChainID       string `json:"chain_id"`
+		Memo          string `json:"memo"`
+		Fee           struct {
+			Amount []sdk.Coin `json:"amount"`
+			Gas    string     `json:"gas"`
+		} `json:"fee"`
+		Msgs []struct {
+			Type string `json:"type"`
+		} `json:"msgs"`
+	}
+	require.NoError(t, json.Unmarshal(res.Amino.SignDoc, &aminoDoc))
+	require.Equal(t, "42", aminoDoc.AccountNumber)
+	require.Equal(t, "7", aminoDoc.Sequence)
+	require.Equal(t, "cosmoshub-4", aminoDoc.ChainID)
+	require.Equal(t, "memo", aminoDoc.Memo)
+	require.Equal(t, "1150000", aminoDoc.Fee.Gas)
+	require.Equal(t, "28750uatom", sdk.Coins(aminoDoc.Fee.Amount).String())
+
+	var types []string
+	for _, m := range aminoDoc.Msgs {
+		types = append(types, m.Type)
+	}
+	require.Equal(t, []string{
+		"cosmos-sdk/MsgSend",
+		"cosmos-sdk/MsgDelegate",
+		"cosmos-sdk/MsgUndelegate",
+		"cosmos-sdk/MsgBeginRedelegate",
+		"cosmos-sdk/MsgWithdrawDelegationReward",
+	}, types)
+}
+
+func Test_buildTx_transfer(t *testing.T) {
+	signer := testAddress(t, "cosmos", 1)
+	receiver := testAddress(t, "osmo", 2)
+
+	routes := map[string]transferRoute{
+		"osmosis": {
+			channel:        "channel-141",
+			receiverPrefix: "osmo",
+			timeout: IBCTimeoutResponse{
+				TimeoutHeight:    IBCHeight{RevisionNumber: 1, RevisionHeight: 5000},
+				TimeoutTimestamp: 1651000000000000000,
+			},
+		},
+	}
+
+	req := BuildTxRequest{
+		Signer:        signer,
+		FeeDenom:      "ustake",
+		GasPriceLevel: "low",
+		Gas:           200000,
+		Messages: []BuildTxMsg{
+			{Type: BuildMsgTransfer, DestChain: "osmosis", Receiver: receiver, Amount: coins(t, "10uatom")},
+		},
+	}
+
+	res, err := buildTx(buildChain, tracelistener.AuthRow{}, req, routes)
+	require.NoError(t, err)
+	require.Equal(t, "200000ustake", res.Fee.String())
+	require.Equal(t, uint64(200000), res.Gas)
+	require.NotNil(t, res.IBCTimeout)
+	require.Equal(t, uint64(5000), res.IBCTimeout.TimeoutHeight.RevisionHeight)
+
+	var body sdktx.TxBody
+	require.NoError(t, body.Unmarshal(res.Direct.BodyBytes))
+	require.Len(t, body.Messages, 1)
+	require.Equal(t, ibcTransferMsgType, body.Messages[0].TypeUrl)
+
+	fields := protoFields(t, body.Messages[0].Value)
+	require.Equal(t, []byte("transfer"), fields[1])
+	require.Equal(t, []byte("channel-141"), fields[2])
+	require.Equal(t, []byte(signer), fields[4])
+	require.Equal(t, []byte(receiver), fields[5])
+	require.Equal(t, uint64(1651000000000000000), fields[7])
+
+	var token sdk.Coin
+	require.NoError(t, token.Unmarshal(fields[3].([]byte)))
+	require.Equal(t, "10uatom", token.String())
+
+	height := protoFields(t, fields[6].([]byte))
+	require.Equal(t, uint64(1), height[1])
+	require.Equal(t, uint64(5000), height[2])
+
+	// no pub_key, the signer already sent txs
+	var authInfo sdktx.AuthInfo
+	require.NoError(t, authInfo.Unmarshal(res.Direct.AuthInfoBytes))
+	require.Nil(t, authInfo.SignerInfos[0].PublicKey)
+
+	require.Contains(t, string(res.Amino.SignDoc), `"msgs":[{"type":"cosmos-sdk/MsgTransfer","value":{"receiver":"`+receiver+`","sender":"`+signer+`","source_channel":"channel-141","source_port":"transfer","timeout_height":{"revision_height":"5000","revision_number":"1"},"timeout_timestamp":"1651000000000000000","token":{"amount":"10","denom":"uatom"}}}]`)
+}
+
+func Test_buildTx_errors(t *testing.T) {
+	signer := testAddress(t, "cosmos", 1)
+	validator := testAddress(t, "cosmosvaloper", 2)
+	send := BuildTxMsg{Type: BuildMsgSend, ToAddress: testAddress(t, "cosmos", 3), Amount: coins(t, "10uatom")}
+
+	tests := []struct {
+		name string
+		req  BuildTxRequest
+	}{
+		{"no message", BuildTxRequest{Signer: signer}},
+		{"unknown message type", BuildTxRequest{Signer: signer, Messages: []BuildTxMsg{{Type: "swap"}}}},
+		{"wrong receiver prefix", BuildTxRequest{Signer: signer, Messages: []BuildTxMsg{{Type: BuildMsgSend, ToAddress: testAddress(t, "osmo", 3), Amount: coins(t, "10uatom")}}}},
+		{"account address as validator", BuildTxRequest{Signer: signer, Messages: []BuildTxMsg{{Type: BuildMsgDelegate, ValidatorAddress: signer, Amount: coins(t, "10uatom")}}}},
+		{"several coins delegated", BuildTxRequest{Signer: signer, Messages: []BuildTxMsg{{Type: BuildMsgDelegate, ValidatorAddress: validator, Amount: coins(t, "10uatom,10ustake")}}}},
+		{"no amount sent", BuildTxRequest{Signer: signer, Messages: []BuildTxMsg{{Type: BuildMsgSend, ToAddress: testAddress(t, "cosmos", 3)}}}},
+		{"transfer without route", BuildTxRequest{Signer: signer, Messages: []BuildTxMsg{{Type: BuildMsgTransfer, DestChain: "osmosis", Receiver: testAddress(t, "osmo", 3), Amount: coins(t, "10uatom")}}}},
+		{"unknown fee denom", BuildTxRequest{Signer: signer, FeeDenom: "uosmo", Messages: []BuildTxMsg{send}}},
+		{"unknown gas price level", BuildTxRequest{Signer: signer, GasPriceLevel: "max", Messages: []BuildTxMsg{send}}},
+		{"pub key of another account", BuildTxRequest{Signer: signer, PubKey: secp256k1.GenPrivKey().PubKey().Bytes(), Messages: []BuildTxMsg{send}}},
+		{"invalid pub key", BuildTxRequest{Signer: signer, PubKey: []byte("key"), Messages: []BuildTxMsg{send}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildTx(buildChain, tracelistener.AuthRow{}, tt.req, nil)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/emerishq/emeris-utils/store"
+)
+
+// Broadcast modes of POST /tx/:chain.
+const (
+	// broadcastModeAsync returns as soon as the tx is relayed, with a ticket.
+	broadcastModeAsync = "async"
+	// broadcastModeCommit waits for the tx to be included in a block.
+	broadcastModeCommit = "commit"
+)
+
+const (
+	defaultCommitTimeout = 30 * time.Second
+	// maxCommitTimeout bounds the time a request can be kept open in commit
+	// mode, whatever the timeout asked for.
+	maxCommitTimeout   = 60 * time.Second
+	commitPollInterval = time.Second
+
+	// ticketPending is the status of tickets whose tx wasn't seen in a block
+	// yet, any other status means the tx was processed.
+	ticketPending = "pending"
+
+	// txResponsePath may need updates if the sdk-service tx format changes
+	txResponsePath = "tx_response"
+)
+
+// broadcastMode parses the broadcast mode and timeout query parameters,
+// returning whether to wait for the tx to be committed and for how long.
+func broadcastMode(mode, timeout string) (bool, time.Duration, error) {
+	switch mode {
+	case "", broadcastModeAsync:
+		return false, 0, nil
+	case broadcastModeCommit:
+	default:
+		return false, 0, fmt.Errorf("unknown broadcast mode %q, must be %s or %s", mode, broadcastModeAsync, broadcastModeCommit)
+	}
+
+	if timeout == "" {
+		return true, defaultCommitTimeout, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return false, 0, fmt.Errorf("invalid timeout %q, must be a positive duration such as 30s", timeout)
+	}
+
+	if d > maxCommitTimeout {
+		d = maxCommitTimeout
+	}
+
+	return true, d, nil
+}
+
+// txResult parses the result of a tx, as returned by sdk-service QueryTx.
+// It returns nil if the tx isn't in a block.
+func txResult(data []byte) *TxResult {
+	res := gjson.GetBytes(data, txResponsePath)
+	if height := res.Get("height").Int(); height <= 0 {
+		return nil
+	}
+
+	ret := &TxResult{
+		Height:    res.Get("height").Int(),
+		Code:      uint32(res.Get("code").Uint()),
+		Codespace: res.Get("codespace").String(),
+		GasWanted: res.Get("gas_wanted").Int(),
+		GasUsed:   res.Get("gas_used").Int(),
+		RawLog:    res.Get("raw_log").String(),
+	}
+
+	if events := res.Get("events"); events.IsArray() {
+		ret.Events = []byte(events.Raw)
+	}
+
+	return ret
+}
+
+// commitWaiter waits for a relayed tx to be included in a block.
+type commitWaiter struct {
+	// ticket returns the ticket of the tx.
+	ticket func() (store.Ticket, error)
+	// queryTx returns the tx as returned by sdk-service QueryTx.
+	queryTx  func(ctx context.Context) ([]byte, error)
+	interval time.Duration
+}
+
+// wait polls the ticket and QueryTx until the tx shows up in a block, and
+// returns its result.
+// It returns nil if ctx is done first.
+func (w commitWaiter) wait(ctx context.Context) *TxResult {
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+
+	for {
+		if res := w.poll(ctx); res != nil {
+			return res
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// poll returns the result of the tx if it's known.
+// Until the tx is indexed by the node, a processed ticket is reported as is.
+func (w commitWaiter) poll(ctx context.Context) *TxResult {
+	// errors are expected until the tx is in a block, the tx can't be found
+	if data, err := w.queryTx(ctx); err == nil {
+		if res := txResult(data); res != nil {
+			return res
+		}
+	}
+
+	ticket, err := w.ticket()
+	if err != nil || ticket.Status == ticketPending || ticket.Status == "" {
+		return nil
+	}
+
+	if ticket.Error == "" {
+		// processed successfully but not indexed yet, wait for the details
+		return nil
+	}
+
+	return &TxResult{
+		Height: ticket.Height,
+		Error:  ticket.Error,
+	}
+}
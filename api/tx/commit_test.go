@@ -0,0 +1,134 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_broadcastMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		timeout     string
+		wantCommit  bool
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{name: "default", wantCommit: false},
+		{name: "async", mode: "async", timeout: "10s", wantCommit: false},
+		{name: "commit default timeout", mode: "commit", wantCommit: true, wantTimeout: defaultCommitTimeout},
+		{name: "commit", mode: "commit", timeout: "10s", wantCommit: true, wantTimeout: 10 * time.Second},
+		{name: "commit bounded", mode: "commit", timeout: "10m", wantCommit: true, wantTimeout: maxCommitTimeout},
+		{name: "invalid timeout", mode: "commit", timeout: "ten", wantErr: true},
+		{name: "negative timeout", mode: "commit", timeout: "-1s", wantErr: true},
+		{name: "unknown mode", mode: "block", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit, timeout, err := broadcastMode(tt.mode, tt.timeout)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCommit, commit)
+			require.Equal(t, tt.wantTimeout, timeout)
+		})
+	}
+}
+
+const committedTx = `{"tx_response":{"height":"1234","txhash":"ABCD","codespace":"sdk","code":5,"gas_wanted":"200000","gas_used":"81234","raw_log":"insufficient funds","events":[{"type":"tx","attributes":[]}]}}`
+
+func Test_txResult(t *testing.T) {
+	require.Nil(t, txResult([]byte(`{"tx_response":{"height":"0"}}`)))
+	require.Nil(t, txResult([]byte(`{}`)))
+
+	res := txResult([]byte(committedTx))
+	require.NotNil(t, res)
+	require.Equal(t, int64(1234), res.Height)
+	require.Equal(t, uint32(5), res.Code)
+	require.Equal(t, "sdk", res.Codespace)
+	require.Equal(t, int64(200000), res.GasWanted)
+	require.Equal(t, int64(81234), res.GasUsed)
+	require.Equal(t, "insufficient funds", res.RawLog)
+	require.JSONEq(t, `[{"type":"tx","attributes":[]}]`, string(res.Events))
+}
+
+func Test_commitWaiter(t *testing.T) {
+	notFound := func(context.Context) ([]byte, error) {
+		return nil, fmt.Errorf("tx not found")
+	}
+
+	pending := func() (store.Ticket, error) {
+		return store.Ticket{Status: "pending"}, nil
+	}
+
+	t.Run("committed after some polls", func(t *testing.T) {
+		polls := 0
+		w := commitWaiter{
+			ticket: pending,
+			queryTx: func(context.Context) ([]byte, error) {
+				polls++
+				if polls < 3 {
+					return nil, fmt.Errorf("tx not found")
+				}
+
+				return []byte(committedTx), nil
+			},
+			interval: time.Millisecond,
+		}
+
+		res := w.wait(context.Background())
+		require.NotNil(t, res)
+		require.Equal(t, int64(1234), res.Height)
+		require.Equal(t, 3, polls)
+	})
+
+	t.Run("failed ticket", func(t *testing.T) {
+		w := commitWaiter{
+			ticket: func() (store.Ticket, error) {
+				return store.Ticket{Status: "failed", Height: 12, Error: "out of gas"}, nil
+			},
+			queryTx:  notFound,
+			interval: time.Millisecond,
+		}
+
+		res := w.wait(context.Background())
+		require.Equal(t, &TxResult{Height: 12, Error: "out of gas"}, res)
+	})
+
+	t.Run("complete ticket waits for the tx details", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		w := commitWaiter{
+			ticket: func() (store.Ticket, error) {
+				return store.Ticket{Status: "complete", Height: 12}, nil
+			},
+			queryTx:  notFound,
+			interval: time.Millisecond,
+		}
+
+		require.Nil(t, w.wait(ctx))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		w := commitWaiter{
+			ticket:   pending,
+			queryTx:  notFound,
+			interval: time.Millisecond,
+		}
+
+		require.Nil(t, w.wait(ctx))
+	})
+}
@@ -0,0 +1,303 @@
+package tx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/std"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	"github.com/emerishq/emeris-utils/logging"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+)
+
+// ibcTransferMsgType is decoded from sdk-service metadata, since ibc-go isn't
+// a dependency of the api-server.
+const ibcTransferMsgType = "/ibc.applications.transfer.v1.MsgTransfer"
+
+// msgRegistry resolves the messages decoded locally.
+var msgRegistry = func() codectypes.InterfaceRegistry {
+	r := codectypes.NewInterfaceRegistry()
+	std.RegisterInterfaces(r)
+	banktypes.RegisterInterfaces(r)
+	stakingtypes.RegisterInterfaces(r)
+	distrtypes.RegisterInterfaces(r)
+	govtypes.RegisterInterfaces(r)
+	authz.RegisterInterfaces(r)
+
+	return r
+}()
+
+var msgCodec = codec.NewProtoCodec(msgRegistry)
+
+// DecodeTx decodes a signed transaction.
+// @Summary Decodes a transaction.
+// @Tags Tx
+// @ID decodeTx
+// @Description Decodes a signed transaction into its messages, signers, fee, gas limit, memo and timeout height.
+// @Description Messages of unknown types are only returned with their type URL.
+// @Param chainName path string true "chain name"
+// @Param tx body DecodeTxRequest true "transaction to decode"
+// @Produce json
+// @Success 200 {object} DecodeTxResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /tx/{chainName}/decode [post]
+func DecodeTx(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+		var req DecodeTxRequest
+
+		chainName := c.Param("chain")
+
+		if err := c.BindJSON(&req); err != nil {
+			e := apierrors.New("tx", fmt.Sprintf("failed to parse JSON"), http.StatusBadRequest).WithLogContext(
+				fmt.Errorf("Failed to parse JSON: %w", err),
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		chain, err := db.Chain(ctx, chainName)
+		if err != nil {
+			e := apierrors.New(
+				"chains",
+				fmt.Sprintf("cannot retrieve chain with name %v", chainName),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot retrieve chain: %w", err),
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		client, e := sdkServiceClients.GetSDKServiceClient(chain.MajorSDKVersion())
+		if e != nil {
+			_ = c.Error(e)
+			return
+		}
+
+		// messages sdk-service can't decode are still decoded locally
+		var metadata []*sdkutilities.MsgMetadata
+		txMetadata, err := client.TxMetadata(ctx, &sdkutilities.TxMetadataPayload{
+			TxBytes: req.TxBytes,
+		})
+		if err != nil {
+			logger.Debugw("cannot retrieve tx metadata from sdk-service", "chain", chainName, "error", err)
+		} else {
+			metadata = txMetadata.MessagesMetadata
+		}
+
+		res, err := decodeTxView(req.TxBytes, chain.NodeInfo.Bech32Config.Bech32PrefixAccAddr(), metadata)
+		if err != nil {
+			e := apierrors.New(
+				"tx",
+				fmt.Sprintf("malformed tx, %v", err),
+				http.StatusBadRequest,
+			).WithLogContext(
+				fmt.Errorf("cannot decode tx: %w", err),
+				"name",
+				chainName,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// decodeTxView decodes the signed tx txBytes, encoding addresses with
+// prefix.
+// metadata holds the messages metadata returned by sdk-service, if any.
+func decodeTxView(txBytes []byte, prefix string, metadata []*sdkutilities.MsgMetadata) (DecodeTxResponse, error) {
+	var raw sdktx.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		return DecodeTxResponse{}, fmt.Errorf("cannot decode tx: %w", err)
+	}
+
+	var body sdktx.TxBody
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		return DecodeTxResponse{}, fmt.Errorf("cannot decode tx body: %w", err)
+	}
+
+	var authInfo sdktx.AuthInfo
+	if err := authInfo.Unmarshal(raw.AuthInfoBytes); err != nil {
+		return DecodeTxResponse{}, fmt.Errorf("cannot decode tx auth info: %w", err)
+	}
+
+	ret := DecodeTxResponse{
+		Messages:      []DecodedMsg{},
+		Signers:       []DecodedSigner{},
+		Fee:           []sdktypes.Coin{},
+		Memo:          body.Memo,
+		TimeoutHeight: body.TimeoutHeight,
+	}
+
+	for i, m := range body.Messages {
+		value, err := decodeMsg(m)
+		if err != nil {
+			return DecodeTxResponse{}, fmt.Errorf("cannot decode message %d: %w", i, err)
+		}
+
+		if value == nil && m.TypeUrl == ibcTransferMsgType && i < len(metadata) && metadata[i] != nil {
+			if value, err = ibcTransferValue(metadata[i].IbcTransferMetadata); err != nil {
+				return DecodeTxResponse{}, fmt.Errorf("cannot decode message %d: %w", i, err)
+			}
+		}
+
+		ret.Messages = append(ret.Messages, DecodedMsg{
+			TypeURL: m.TypeUrl,
+			Value:   value,
+		})
+	}
+
+	for i, si := range authInfo.SignerInfos {
+		address, err := signerAddress(si)
+		if err != nil {
+			return DecodeTxResponse{}, err
+		}
+
+		signer := DecodedSigner{
+			Sequence: si.Sequence,
+		}
+
+		if address != nil {
+			if signer.Address, err = bech32.ConvertAndEncode(prefix, address); err != nil {
+				return DecodeTxResponse{}, fmt.Errorf("cannot encode signer %d address: %w", i, err)
+			}
+		}
+
+		if si.PublicKey != nil {
+			signer.PubKeyType = si.PublicKey.TypeUrl
+		}
+
+		if single := si.ModeInfo.GetSingle(); single != nil {
+			signer.SignMode = single.Mode.String()
+		}
+
+		ret.Signers = append(ret.Signers, signer)
+	}
+
+	if fee := authInfo.Fee; fee != nil {
+		for _, c := range fee.Amount {
+			amount, ok := sdktypes.NewIntFromString(c.Amount.String())
+			if !ok {
+				return DecodeTxResponse{}, fmt.Errorf("invalid fee amount %s", c)
+			}
+
+			ret.Fee = append(ret.Fee, sdktypes.Coin{Denom: c.Denom, Amount: amount})
+		}
+
+		ret.GasLimit = fee.GasLimit
+		ret.FeePayer = fee.Payer
+		ret.FeeGranter = fee.Granter
+	}
+
+	return ret, nil
+}
+
+// decodeMsg decodes the message m into its JSON representation.
+// Messages of types not known locally are returned as nil.
+func decodeMsg(m *codectypes.Any) (json.RawMessage, error) {
+	// unknown type URLs aren't an error, the message just isn't decoded
+	if _, err := msgRegistry.Resolve(m.TypeUrl); err != nil {
+		return nil, nil
+	}
+
+	var msg sdk.Msg
+	if err := msgRegistry.UnpackAny(m, &msg); err != nil {
+		return nil, err
+	}
+
+	return msgCodec.MarshalJSON(msg)
+}
+
+// ibcTransferValue returns the JSON representation of a MsgTransfer, as
+// ibc-go would encode it, from its sdk-service metadata.
+func ibcTransferValue(m *sdkutilities.IBCTransferMetadata) (json.RawMessage, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+
+		return *s
+	}
+
+	derefUint := func(u *uint64) string {
+		if u == nil {
+			return "0"
+		}
+
+		return strconv.FormatUint(*u, 10)
+	}
+
+	value := ibcTransferMsg{
+		SourcePort:       deref(m.SourcePort),
+		SourceChannel:    deref(m.SourceChannel),
+		Sender:           deref(m.Sender),
+		Receiver:         deref(m.Receiver),
+		TimeoutHeight:    ibcTransferHeight{RevisionNumber: "0", RevisionHeight: "0"},
+		TimeoutTimestamp: derefUint(m.TiemoutTimestamp),
+	}
+
+	if m.Token != nil {
+		value.Token = &ibcTransferToken{Denom: m.Token.Denom, Amount: m.Token.Amount}
+	}
+
+	if m.TimeoutHeight != nil {
+		value.TimeoutHeight = ibcTransferHeight{
+			RevisionNumber: derefUint(m.TimeoutHeight.RevisionNumber),
+			RevisionHeight: derefUint(m.TimeoutHeight.RevisionHeight),
+		}
+	}
+
+	return json.Marshal(value)
+}
+
+type ibcTransferMsg struct {
+	SourcePort       string            `json:"source_port"`
+	SourceChannel    string            `json:"source_channel"`
+	Token            *ibcTransferToken `json:"token"`
+	Sender           string            `json:"sender"`
+	Receiver         string            `json:"receiver"`
+	TimeoutHeight    ibcTransferHeight `json:"timeout_height"`
+	TimeoutTimestamp string            `json:"timeout_timestamp"`
+}
+
+type ibcTransferToken struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type ibcTransferHeight struct {
+	RevisionNumber string `json:"revision_number"`
+	RevisionHeight string `json:"revision_height"`
+}
@@ -0,0 +1,129 @@
+package tx
+
+import (
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_decodeTxView(t *testing.T) {
+	pk := secp256k1.GenPrivKey().PubKey().(*secp256k1.PubKey)
+	pkBytes, err := pk.Marshal()
+	require.NoError(t, err)
+
+	sender, err := bech32.ConvertAndEncode("osmo", pk.Address())
+	require.NoError(t, err)
+
+	send, err := codectypes.NewAnyWithValue(&banktypes.MsgSend{
+		FromAddress: sender,
+		ToAddress:   "osmo1receiver",
+		Amount:      sdk.NewCoins(sdk.NewInt64Coin("uosmo", 42)),
+	})
+	require.NoError(t, err)
+
+	body := sdktx.TxBody{
+		Messages: []*codectypes.Any{
+			send,
+			{TypeUrl: ibcTransferMsgType, Value: []byte("transfer")},
+			{TypeUrl: "/osmosis.gamm.v1beta1.MsgSwapExactAmountIn", Value: []byte("swap")},
+		},
+		Memo:          "payout",
+		TimeoutHeight: 1000,
+	}
+	bodyBytes, err := body.Marshal()
+	require.NoError(t, err)
+
+	authInfo := sdktx.AuthInfo{
+		SignerInfos: []*sdktx.SignerInfo{
+			{
+				PublicKey: &codectypes.Any{TypeUrl: secp256k1PubKeyType, Value: pkBytes},
+				ModeInfo: &sdktx.ModeInfo{
+					Sum: &sdktx.ModeInfo_Single_{Single: &sdktx.ModeInfo_Single{Mode: signing.SignMode_SIGN_MODE_DIRECT}},
+				},
+				Sequence: 7,
+			},
+		},
+		Fee: &sdktx.Fee{
+			Amount:   sdk.NewCoins(sdk.NewInt64Coin("uosmo", 5000)),
+			GasLimit: 200000,
+		},
+	}
+	authInfoBytes, err := authInfo.Marshal()
+	require.NoError(t, err)
+
+	txBytes, err := (&sdktx.TxRaw{
+		BodyBytes:     bodyBytes,
+		AuthInfoBytes: authInfoBytes,
+		Signatures:    [][]byte{[]byte("signature")},
+	}).Marshal()
+	require.NoError(t, err)
+
+	port, channel, receiver := "transfer", "channel-0", "cosmos1receiver"
+	revision, height, timestamp := uint64(4), uint64(1234), uint64(1651000000000000000)
+	metadata := []*sdkutilities.MsgMetadata{
+		{MsgType: "send"},
+		{
+			MsgType: "transfer",
+			IbcTransferMetadata: &sdkutilities.IBCTransferMetadata{
+				SourcePort:       &port,
+				SourceChannel:    &channel,
+				Token:            &sdkutilities.Coin{Denom: "uosmo", Amount: "10"},
+				Sender:           &sender,
+				Receiver:         &receiver,
+				TimeoutHeight:    &sdkutilities.IBCHeight{RevisionNumber: &revision, RevisionHeight: &height},
+				TiemoutTimestamp: &timestamp,
+			},
+		},
+	}
+
+	res, err := decodeTxView(txBytes, "osmo", metadata)
+	require.NoError(t, err)
+
+	require.Equal(t, "payout", res.Memo)
+	require.Equal(t, uint64(1000), res.TimeoutHeight)
+	require.Equal(t, uint64(200000), res.GasLimit)
+	require.Len(t, res.Fee, 1)
+	require.Equal(t, "5000uosmo", res.Fee[0].String())
+
+	require.Equal(t, []DecodedSigner{{
+		Address:    sender,
+		PubKeyType: secp256k1PubKeyType,
+		Sequence:   7,
+		SignMode:   "SIGN_MODE_DIRECT",
+	}}, res.Signers)
+
+	require.Len(t, res.Messages, 3)
+
+	require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", res.Messages[0].TypeURL)
+	require.JSONEq(t, `{"from_address":"`+sender+`","to_address":"osmo1receiver","amount":[{"denom":"uosmo","amount":"42"}]}`, string(res.Messages[0].Value))
+
+	require.Equal(t, ibcTransferMsgType, res.Messages[1].TypeURL)
+	require.JSONEq(t, `{
+		"source_port":"transfer",
+		"source_channel":"channel-0",
+		"token":{"denom":"uosmo","amount":"10"},
+		"sender":"`+sender+`",
+		"receiver":"cosmos1receiver",
+		"timeout_height":{"revision_number":"4","revision_height":"1234"},
+		"timeout_timestamp":"1651000000000000000"
+	}`, string(res.Messages[1].Value))
+
+	require.Equal(t, "/osmosis.gamm.v1beta1.MsgSwapExactAmountIn", res.Messages[2].TypeURL)
+	require.Nil(t, res.Messages[2].Value)
+
+	// without sdk-service metadata, transfers aren't decoded
+	res, err = decodeTxView(txBytes, "osmo", nil)
+	require.NoError(t, err)
+	require.Nil(t, res.Messages[1].Value)
+
+	_, err = decodeTxView([]byte("not a tx"), "osmo", nil)
+	require.Error(t, err)
+}
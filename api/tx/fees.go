@@ -0,0 +1,99 @@
+package tx
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	potypes "github.com/emerishq/emeris-price-oracle/price-oracle/types"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+
+	"github.com/emerishq/demeris-api-server/lib/poclient"
+)
+
+// fiatCurrency is the currency fee quotes are converted to, as priced by the
+// price oracle.
+const fiatCurrency = "USD"
+
+// priceFunc returns the price of symbol, as poclient.POClient.GetPrice.
+type priceFunc func(symbol string) (poclient.Price, error)
+
+// adjustedGas returns the gas limit of a tx using gasUsed, with a margin of
+// adjustment.
+func adjustedGas(gasUsed uint64, adjustment float64) uint64 {
+	return uint64(math.Ceil(float64(gasUsed) * adjustment))
+}
+
+// feeQuotes returns the fee paying for gasLimit in every fee token of chain,
+// at every gas price level.
+// Fees are converted to fiat with price if it's set and the token is priced.
+func feeQuotes(chain cns.Chain, gasLimit uint64, price priceFunc) ([]FeeQuote, error) {
+	ret := []FeeQuote{}
+	for _, ft := range chain.FeeTokens() {
+		quote := FeeQuote{
+			Denom:  ft.Name,
+			Ticker: ft.Ticker,
+		}
+
+		// a token priced once is priced the same at every level
+		var fiatPrice *float64
+		if price != nil && ft.FetchPrice && ft.Ticker != "" {
+			if p, err := price(ft.Ticker + potypes.USDT); err == nil {
+				fiatPrice = &p.Price
+			}
+		}
+
+		levels := []struct {
+			gasPrice float64
+			dest     *FeeLevel
+		}{
+			{ft.GasPriceLevels.Low, &quote.Low},
+			{ft.GasPriceLevels.Average, &quote.Average},
+			{ft.GasPriceLevels.High, &quote.High},
+		}
+
+		for _, l := range levels {
+			amount, err := minFee(l.gasPrice, gasLimit)
+			if err != nil {
+				return nil, fmt.Errorf("cannot compute fee in %s: %w", ft.Name, err)
+			}
+
+			*l.dest = FeeLevel{
+				GasPrice: l.gasPrice,
+				Fee:      sdktypes.Coin{Denom: ft.Name, Amount: amount},
+			}
+
+			if fiatPrice != nil {
+				value := fiatValue(amount, ft.Precision, *fiatPrice)
+				l.dest.FiatValue = &value
+			}
+		}
+
+		ret = append(ret, quote)
+	}
+
+	return ret, nil
+}
+
+// fiatValue returns the fiat value of amount base units of a token with
+// precision decimals, priced at price.
+func fiatValue(amount sdktypes.Int, precision int64, price float64) float64 {
+	v := new(big.Float).SetInt(amount.BigInt())
+	v.Quo(v, new(big.Float).SetFloat64(math.Pow10(int(precision))))
+	v.Mul(v, big.NewFloat(price))
+
+	f, _ := v.Float64()
+
+	return f
+}
+
+// prices returns the priceFunc querying the price oracle at url, or nil if
+// url is empty.
+func prices(url string) priceFunc {
+	if url == "" {
+		return nil
+	}
+
+	return poclient.NewPOClient(url).GetPrice
+}
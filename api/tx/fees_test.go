@@ -0,0 +1,67 @@
+package tx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emerishq/demeris-api-server/lib/poclient"
+)
+
+func Test_adjustedGas(t *testing.T) {
+	require.Equal(t, uint64(100000), adjustedGas(100000, 1))
+	require.Equal(t, uint64(130000), adjustedGas(100000, 1.3))
+	require.Equal(t, uint64(2), adjustedGas(1, 1.5))
+}
+
+func Test_feeQuotes(t *testing.T) {
+	chain := cns.Chain{
+		ChainName: "cosmos-hub",
+		Denoms: cns.DenomList{
+			{Name: "uatom", Ticker: "ATOM", Precision: 6, FetchPrice: true, FeeToken: true, GasPriceLevels: cns.GasPrice{Low: 0.01, Average: 0.025, High: 0.04}},
+			{Name: "ustake", Ticker: "STAKE", Precision: 6, FetchPrice: true, FeeToken: true, GasPriceLevels: cns.GasPrice{Low: 0.5, Average: 1, High: 2}},
+			{Name: "uother", Ticker: "OTHER", FetchPrice: true},
+		},
+	}
+
+	price := func(symbol string) (poclient.Price, error) {
+		if symbol != "ATOMUSDT" {
+			return poclient.Price{}, fmt.Errorf("cannot get price for given symbol: %s", symbol)
+		}
+
+		return poclient.Price{Symbol: symbol, Price: 10}, nil
+	}
+
+	quotes, err := feeQuotes(chain, 200000, price)
+	require.NoError(t, err)
+	require.Len(t, quotes, 2)
+
+	atom := quotes[0]
+	require.Equal(t, "uatom", atom.Denom)
+	require.Equal(t, "ATOM", atom.Ticker)
+	require.Equal(t, "2000uatom", atom.Low.Fee.String())
+	require.Equal(t, "5000uatom", atom.Average.Fee.String())
+	require.Equal(t, "8000uatom", atom.High.Fee.String())
+	require.Equal(t, 0.025, atom.Average.GasPrice)
+	require.NotNil(t, atom.Low.FiatValue)
+	require.InDelta(t, 0.02, *atom.Low.FiatValue, 1e-9)
+	require.InDelta(t, 0.05, *atom.Average.FiatValue, 1e-9)
+	require.InDelta(t, 0.08, *atom.High.FiatValue, 1e-9)
+
+	// unpriced tokens are still quoted
+	stake := quotes[1]
+	require.Equal(t, "100000ustake", stake.Low.Fee.String())
+	require.Equal(t, "400000ustake", stake.High.Fee.String())
+	require.Nil(t, stake.Low.FiatValue)
+
+	// without price oracle
+	quotes, err = feeQuotes(chain, 200000, nil)
+	require.NoError(t, err)
+	require.Nil(t, quotes[0].Low.FiatValue)
+
+	quotes, err = feeQuotes(cns.Chain{}, 200000, price)
+	require.NoError(t, err)
+	require.Empty(t, quotes)
+}
@@ -2,7 +2,6 @@ package tx
 
 import (
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -14,35 +13,15 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-// paths for packet_sequence and tx from the events,
-// can be updated to more readable format after typed events are introduced in sdk
-const (
-	// packetSequencePath may need updates if ibc-go/transfer events change
-	packetSequencePath = `tx_response.logs.#.events.#(type=="send_packet").attributes.#(key=="packet_sequence").value`
-	// txPath may need updates if tendermint response changes
-	txPath  = "result.txs.0.hash"
-	timeout = 10 * time.Second
-)
-
-// getIBCSeqFromTx returns a list of sequence numbers gotten from data,
-// from the sent IBC packet event.
-// If no IBC sequence numbers are found, the resulting slice is empty.
-func getIBCSeqFromTx(data []byte) []string {
-	raw := gjson.GetBytes(data, packetSequencePath).Array()
-
-	ret := make([]string, 0, len(raw))
-	for _, r := range raw {
-		ret = append(ret, r.String())
-	}
+const timeout = 10 * time.Second
 
-	return ret
-}
-
-// GetDestTx returns tx hash on destination chain.
-// @Summary Gets tx hash on destination chain.
+// GetDestTx returns the lifecycle of the IBC packets sent by a tx.
+// @Summary Gets the lifecycle of the IBC packets sent by a tx.
 // @Tags Tx
 // @ID destTx
-// @Description Gets tx hash on destination chain.
+// @Description Tracks every IBC packet sent by a tx: its reception on the destination chain, its acknowledgement
+// @Description (successful or not), its timeout and its refund on the source chain.
+// @Description tx_hash is the hash of the tx which received the first packet on the destination chain, if any.
 // @Param srcChain path string true "source chain name"
 // @Param destChainName path string true "destination chain name"
 // @Param txHash path string true "tx hash on src chain"
@@ -50,7 +29,7 @@ func getIBCSeqFromTx(data []byte) []string {
 // @Success 200 {object} DestTxResponse
 // @Failure 500,403 {object} apierrors.UserFacingError
 // @Router /tx/{srcChain}/{destChain}/{txHash} [get]
-func GetDestTx(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients) gin.HandlerFunc {
+func GetDestTx(db *database.Database, sdkServiceClients sdkservice.SDKServiceClients, rpc TendermintRPC) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 
@@ -119,19 +98,14 @@ func GetDestTx(db *database.Database, sdkServiceClients sdkservice.SDKServiceCli
 			return
 		}
 
-		// This query always returns an array of sequence numbers.
-		// Emeris-generated IBC transfers are always sent out alone, meaning that
-		// there are no more than 1 IBC transfer per tx.
-		// This code is ready to be adapted to support multiple IBC transfer/transaction, but
-		// for now we just get the first seq number found and roll with it.
-		r := getIBCSeqFromTx(sdkRes)
-		if len(r) == 0 {
+		packets := sentPackets(sdkRes)
+		if len(packets) == 0 {
 			e := apierrors.New(
 				"chains",
 				fmt.Sprintf("provided transaction is not ibc transfer"),
 				http.StatusBadRequest,
 			).WithLogContext(
-				fmt.Errorf("provided transaction is not ibc transfer: %w", err),
+				fmt.Errorf("provided transaction is not ibc transfer"),
 				"txHash",
 				txHash,
 				"src srcChainInfo name",
@@ -142,57 +116,45 @@ func GetDestTx(db *database.Database, sdkServiceClients sdkservice.SDKServiceCli
 			return
 		}
 
-		seqNum := r[0]
-		url := fmt.Sprintf("http://%s:26657/tx_search?query=\"recv_packet.packet_sequence=%s\"", destChainInfo.ChainName, seqNum)
-
-		httpClient := &http.Client{
-			Timeout: timeout,
+		tracker := packetTracker{
+			rpc:         rpc,
+			sourceChain: srcChainInfo.ChainName,
+			destChain:   destChainInfo.ChainName,
 		}
 
-		// we're validating inputs and hence gosec-G107 can be ignored
-		resp, err := httpClient.Get(url) // nolint: gosec
-		if err != nil || resp.StatusCode != http.StatusOK {
-			e := apierrors.New(
-				"chains",
-				fmt.Sprintf("cannot retrieve tx with packet sequence %s on %s", seqNum, destChain),
-				http.StatusBadRequest,
-			).WithLogContext(
-				fmt.Errorf("cannot retrieve destination tx: %w", err),
-				"txHash",
-				txHash,
-				"dest srcChainInfo name",
-				destChain,
-				"status_code",
-				resp.Status,
-			)
-			_ = c.Error(e)
+		height := gjson.GetBytes(sdkRes, "tx_response.height").Uint()
 
-			return
+		res := DestTxResponse{
+			DestChain: destChain,
+			Packets:   make([]PacketLifecycle, 0, len(packets)),
 		}
-		defer resp.Body.Close()
 
-		bz, err := io.ReadAll(resp.Body)
-		if err != nil {
-			e := apierrors.New(
-				"chains",
-				fmt.Sprintf("cannot retrieve tx with packet sequence %s on %s", seqNum, destChain),
-				http.StatusBadRequest,
-			).WithLogContext(
-				fmt.Errorf("cannot retrieve destination tx: %w", err),
-				"txHash",
-				txHash,
-				"dest srcChainInfo name",
-				destChain,
-			)
-			_ = c.Error(e)
+		for _, packet := range packets {
+			lifecycle, err := tracker.track(ctx, txHash, height, packet)
+			if err != nil {
+				e := apierrors.New(
+					"chains",
+					fmt.Sprintf("cannot track packet with sequence %d on %s", packet.Sequence, destChain),
+					http.StatusBadRequest,
+				).WithLogContext(
+					fmt.Errorf("cannot track packet: %w", err),
+					"txHash",
+					txHash,
+					"dest srcChainInfo name",
+					destChain,
+				)
+				_ = c.Error(e)
+
+				return
+			}
+
+			res.Packets = append(res.Packets, lifecycle)
+		}
 
-			return
+		if received := res.Packets[0].Received; received != nil {
+			res.TxHash = received.TxHash
 		}
 
-		otherSideTxHash := gjson.GetBytes(bz, txPath)
-		c.JSON(http.StatusOK, DestTxResponse{
-			DestChain: destChain,
-			TxHash:    otherSideTxHash.String(),
-		})
+		c.JSON(http.StatusOK, res)
 	}
 }
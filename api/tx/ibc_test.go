@@ -1,12 +1,13 @@
 package tx
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
-func Test_getIBCSeqFromTx(t *testing.T) {
+func Test_sentPackets(t *testing.T) {
 	// This test assumes tx_response.logs.events is always present,
 	// because Tendermint events are formatted this way.
 	tests := []struct {
@@ -99,7 +100,10 @@ func Test_getIBCSeqFromTx(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			res := getIBCSeqFromTx([]byte(tt.payload))
+			res := []string{}
+			for _, p := range sentPackets([]byte(tt.payload)) {
+				res = append(res, strconv.FormatUint(p.Sequence, 10))
+			}
 			require.ElementsMatch(t, res, tt.want)
 		})
 	}
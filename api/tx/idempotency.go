@@ -0,0 +1,64 @@
+package tx
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/emerishq/emeris-utils/store"
+)
+
+const (
+	// idempotencyKeyHeader lets clients retry a broadcast without relaying
+	// the tx again, even if they rebuild and re-sign it.
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotencyKeyPrefix = "api-server/idempotency"
+)
+
+// txHash returns the hash of txBytes, as computed by Tendermint.
+func txHash(txBytes []byte) string {
+	return fmt.Sprintf("%X", sha256.Sum256(txBytes))
+}
+
+// idempotencyKey returns the store key holding the tx hash associated to the
+// idempotency key of owner on chainName.
+func idempotencyKey(chainName, owner, key string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", idempotencyKeyPrefix, chainName, owner, key)
+}
+
+// claimIdempotencyKey associates the idempotency key of owner to hash, for as
+// long as tickets live.
+// If key is already associated to a tx, its hash is returned instead.
+func claimIdempotencyKey(ctx context.Context, s *store.Store, chainName, owner, key, hash string) (string, error) {
+	k := idempotencyKey(chainName, owner, key)
+
+	claimed, err := s.Client.SetNX(ctx, k, hash, s.Config.ExpiryTime).Result()
+	if err != nil {
+		return "", fmt.Errorf("cannot claim idempotency key: %w", err)
+	}
+
+	if claimed {
+		return hash, nil
+	}
+
+	prev, err := s.Client.Get(ctx, k).Result()
+	if errors.Is(err, redis.Nil) {
+		// expired in the meantime
+		return claimIdempotencyKey(ctx, s, chainName, owner, key, hash)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("cannot read idempotency key: %w", err)
+	}
+
+	return prev, nil
+}
+
+// releaseIdempotencyKey dissociates key from the tx it was claimed for, so
+// that owner can use it again once broadcasting failed.
+func releaseIdempotencyKey(ctx context.Context, s *store.Store, chainName, owner, key string) error {
+	return s.Client.Del(ctx, idempotencyKey(chainName, owner, key)).Err()
+}
@@ -0,0 +1,62 @@
+package tx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) (*store.Store, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	s, err := store.NewClient(m.Addr())
+	require.NoError(t, err)
+
+	return s, m
+}
+
+func Test_txHash(t *testing.T) {
+	require.Equal(t, "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855", txHash(nil))
+	require.Equal(t, txHash([]byte("tx")), txHash([]byte("tx")))
+	require.NotEqual(t, txHash([]byte("tx")), txHash([]byte("tx2")))
+}
+
+func Test_claimIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	s, m := newTestStore(t)
+
+	hash, err := claimIdempotencyKey(ctx, s, "cosmos-hub", "owner", "key", "AAAA")
+	require.NoError(t, err)
+	require.Equal(t, "AAAA", hash)
+	require.Equal(t, s.Config.ExpiryTime, m.TTL(idempotencyKey("cosmos-hub", "owner", "key")))
+
+	// a re-signed tx sent with the same key gets the first hash
+	hash, err = claimIdempotencyKey(ctx, s, "cosmos-hub", "owner", "key", "BBBB")
+	require.NoError(t, err)
+	require.Equal(t, "AAAA", hash)
+
+	// keys are per chain
+	hash, err = claimIdempotencyKey(ctx, s, "osmosis", "owner", "key", "BBBB")
+	require.NoError(t, err)
+	require.Equal(t, "BBBB", hash)
+
+	// and per owner
+	hash, err = claimIdempotencyKey(ctx, s, "cosmos-hub", "other", "key", "BBBB")
+	require.NoError(t, err)
+	require.Equal(t, "BBBB", hash)
+
+	require.NoError(t, releaseIdempotencyKey(ctx, s, "cosmos-hub", "owner", "key"))
+
+	hash, err = claimIdempotencyKey(ctx, s, "cosmos-hub", "owner", "key", "BBBB")
+	require.NoError(t, err)
+	require.Equal(t, "BBBB", hash)
+
+	m.FastForward(s.Config.ExpiryTime + time.Second)
+
+	hash, err = claimIdempotencyKey(ctx, s, "cosmos-hub", "owner", "key", "CCCC")
+	require.NoError(t, err)
+	require.Equal(t, "CCCC", hash)
+}
@@ -0,0 +1,59 @@
+package tx
+
+import (
+	"strings"
+	"time"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+)
+
+// journalEntry returns the journal entry of the transaction txHash, relayed
+// on chainName for owner.
+func journalEntry(chainName, txHash, owner string, meta TxMeta, submittedAt time.Time) database.TxJournalEntry {
+	msgTypes := []string{}
+	if meta.TxType != "" {
+		msgTypes = strings.Split(meta.TxType, ",")
+	}
+
+	return database.TxJournalEntry{
+		ChainName:   chainName,
+		TxHash:      txHash,
+		Owner:       owner,
+		Signer:      meta.Signer,
+		MsgTypes:    msgTypes,
+		SubmittedAt: submittedAt,
+		Status:      apiutils.TxJournalPending,
+	}
+}
+
+// journalResult returns the journal result of a committed transaction.
+func journalResult(res *TxResult) database.TxJournalResult {
+	ret := database.TxJournalResult{
+		Status: apiutils.TxJournalComplete,
+	}
+
+	if res.Height != 0 {
+		height := res.Height
+		ret.Height = &height
+	}
+
+	if res.Error != "" {
+		e := res.Error
+		ret.Error = &e
+		ret.Status = apiutils.TxJournalFailed
+
+		return ret
+	}
+
+	code := int64(res.Code)
+	ret.Code = &code
+
+	if res.Code != 0 {
+		e := res.RawLog
+		ret.Error = &e
+		ret.Status = apiutils.TxJournalFailed
+	}
+
+	return ret
+}
@@ -0,0 +1,63 @@
+package tx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_journalEntry(t *testing.T) {
+	now := time.Now()
+	entry := journalEntry("cosmos-hub", "AAAA", "owner", TxMeta{
+		TxType: "/cosmos.bank.v1beta1.MsgSend,/cosmos.staking.v1beta1.MsgDelegate",
+		Signer: "cosmos1signer",
+	}, now)
+
+	require.Equal(t, database.TxJournalEntry{
+		ChainName:   "cosmos-hub",
+		TxHash:      "AAAA",
+		Owner:       "owner",
+		Signer:      "cosmos1signer",
+		MsgTypes:    []string{"/cosmos.bank.v1beta1.MsgSend", "/cosmos.staking.v1beta1.MsgDelegate"},
+		SubmittedAt: now,
+		Status:      "pending",
+	}, entry)
+
+	require.Empty(t, journalEntry("cosmos-hub", "AAAA", "owner", TxMeta{}, now).MsgTypes)
+}
+
+func Test_journalResult(t *testing.T) {
+	ptr := func(i int64) *int64 { return &i }
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		res  TxResult
+		want database.TxJournalResult
+	}{
+		{
+			"success",
+			TxResult{Height: 12, GasUsed: 1000},
+			database.TxJournalResult{Status: "complete", Height: ptr(12), Code: ptr(0)},
+		},
+		{
+			"failed execution",
+			TxResult{Height: 12, Code: 5, RawLog: "insufficient funds"},
+			database.TxJournalResult{Status: "failed", Height: ptr(12), Code: ptr(5), Error: str("insufficient funds")},
+		},
+		{
+			"failed ticket",
+			TxResult{Error: "out of gas"},
+			database.TxJournalResult{Status: "failed", Error: str("out of gas")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.res
+			require.Equal(t, tt.want, journalResult(&res))
+		})
+	}
+}
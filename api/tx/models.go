@@ -1,8 +1,14 @@
 package tx
 
 import (
+	"encoding/json"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/emerishq/demeris-backend-models/cns"
 	"github.com/emerishq/emeris-utils/exported/sdktypes"
+
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
 )
 
 type TxRequest struct {
@@ -22,6 +28,21 @@ type TxMeta struct {
 
 type TxResponse struct {
 	Ticket string `json:"ticket"`
+	// Result and TimedOut are only set in commit broadcast mode.
+	Result   *TxResult `json:"result,omitempty"`
+	TimedOut bool      `json:"timed_out,omitempty"`
+}
+
+// TxResult is the outcome of a transaction included in a block.
+type TxResult struct {
+	Height    int64           `json:"height"`
+	Code      uint32          `json:"code"`
+	Codespace string          `json:"codespace,omitempty"`
+	GasWanted int64           `json:"gas_wanted"`
+	GasUsed   int64           `json:"gas_used"`
+	RawLog    string          `json:"raw_log,omitempty"`
+	Events    json.RawMessage `json:"events,omitempty" swaggertype:"array,object"`
+	Error     string          `json:"error,omitempty"`
 }
 
 type TxFeeEstimateReq struct {
@@ -29,12 +50,212 @@ type TxFeeEstimateReq struct {
 }
 
 type TxFeeEstimateRes struct {
-	GasWanted uint64
-	GasUsed   uint64
-	Fees      []sdktypes.Coin
+	GasWanted     uint64
+	GasUsed       uint64
+	Fees          []sdktypes.Coin
+	GasAdjustment float64
+	// GasLimit is GasUsed with GasAdjustment applied, Quotes pay for it.
+	GasLimit     uint64
+	FiatCurrency string
+	Quotes       []FeeQuote
+}
+
+// FeeQuote is the fee of a tx in a fee token, at every gas price level.
+type FeeQuote struct {
+	Denom   string   `json:"denom"`
+	Ticker  string   `json:"ticker,omitempty"`
+	Low     FeeLevel `json:"low"`
+	Average FeeLevel `json:"average"`
+	High    FeeLevel `json:"high"`
+}
+
+// FeeLevel is the fee of a tx at a gas price.
+// FiatValue is only set for tokens with a known price.
+type FeeLevel struct {
+	GasPrice  float64       `json:"gas_price"`
+	Fee       sdktypes.Coin `json:"fee"`
+	FiatValue *float64      `json:"fiat_value,omitempty"`
 }
 
 type DestTxResponse struct {
-	DestChain string `json:"dest_chain"`
+	DestChain string            `json:"dest_chain"`
+	TxHash    string            `json:"tx_hash"`
+	Packets   []PacketLifecycle `json:"packets"`
+}
+
+// PacketLifecycle is the state of an IBC packet, from the source chain to
+// the destination chain and back.
+type PacketLifecycle struct {
+	Sequence         uint64 `json:"sequence"`
+	SourcePort       string `json:"source_port"`
+	SourceChannel    string `json:"source_channel"`
+	DestPort         string `json:"dest_port"`
+	DestChannel      string `json:"dest_channel"`
+	TimeoutHeight    string `json:"timeout_height,omitempty"`
+	TimeoutTimestamp string `json:"timeout_timestamp,omitempty"`
+	Status           string `json:"status"`
+
+	Sent         *PacketEvent `json:"sent"`
+	Received     *PacketEvent `json:"received,omitempty"`
+	Acknowledged *PacketEvent `json:"acknowledged,omitempty"`
+	TimedOut     *PacketEvent `json:"timed_out,omitempty"`
+
+	// AckSuccess is set once the acknowledgement is written on the
+	// destination chain.
+	AckSuccess *bool  `json:"ack_success,omitempty"`
+	AckError   string `json:"ack_error,omitempty"`
+	Refunded   bool   `json:"refunded"`
+}
+
+// PacketEvent locates the tx in which an IBC packet went through a step of
+// its lifecycle.
+type PacketEvent struct {
+	ChainName string `json:"chain_name"`
 	TxHash    string `json:"tx_hash"`
+	Height    uint64 `json:"height"`
+}
+
+// IBCHeight is an ibc-go client height.
+type IBCHeight struct {
+	RevisionNumber uint64 `json:"revision_number"`
+	RevisionHeight uint64 `json:"revision_height"`
+}
+
+type IBCTimeoutResponse struct {
+	SourceChain string `json:"src_chain"`
+	DestChain   string `json:"dest_chain"`
+	// Channel is the primary channel of the source chain to the destination
+	// chain, if any.
+	Channel       string    `json:"channel,omitempty"`
+	TimeoutHeight IBCHeight `json:"timeout_height"`
+	// TimeoutTimestamp is expressed in nanoseconds since the Unix epoch.
+	TimeoutTimestamp uint64    `json:"timeout_timestamp"`
+	LatestHeight     uint64    `json:"latest_height"`
+	LatestBlockTime  time.Time `json:"latest_block_time"`
+	// BlockTime is the observed average block time, in seconds.
+	BlockTime float64 `json:"block_time"`
+	// Margin is the time the transfer is given before timing out, in seconds.
+	Margin float64 `json:"margin"`
+}
+
+type DecodeTxRequest struct {
+	TxBytes []byte `json:"tx_bytes"`
+}
+
+// DecodeTxResponse is the readable view of a signed transaction.
+type DecodeTxResponse struct {
+	Messages      []DecodedMsg    `json:"messages"`
+	Signers       []DecodedSigner `json:"signers"`
+	Fee           []sdktypes.Coin `json:"fee"`
+	FeePayer      string          `json:"fee_payer,omitempty"`
+	FeeGranter    string          `json:"fee_granter,omitempty"`
+	GasLimit      uint64          `json:"gas_limit"`
+	Memo          string          `json:"memo"`
+	TimeoutHeight uint64          `json:"timeout_height"`
+}
+
+// DecodedMsg is a message of a transaction.
+// Value is only set for message types known to the api-server.
+type DecodedMsg struct {
+	TypeURL string          `json:"type_url"`
+	Value   json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// DecodedSigner is a signer of a transaction.
+// Address is only set if it can be derived from the signer public key.
+type DecodedSigner struct {
+	Address    string `json:"address,omitempty"`
+	PubKeyType string `json:"pub_key_type,omitempty"`
+	Sequence   uint64 `json:"sequence"`
+	SignMode   string `json:"sign_mode,omitempty"`
+}
+
+// BuildTxRequest is an unsigned transaction to build, all of its messages
+// being signed by Signer.
+type BuildTxRequest struct {
+	Signer string `json:"signer"`
+	// PubKey is the compressed secp256k1 public key of Signer. It's only
+	// required if Signer never sent a transaction.
+	PubKey   []byte       `json:"pub_key,omitempty"`
+	Messages []BuildTxMsg `json:"messages"`
+	Memo     string       `json:"memo"`
+	// FeeDenom defaults to the first fee token of the chain.
+	FeeDenom string `json:"fee_denom,omitempty"`
+	// GasPriceLevel is low, average or high, average by default.
+	GasPriceLevel string `json:"gas_price_level,omitempty"`
+	// Gas overrides the gas estimated for Messages.
+	Gas uint64 `json:"gas,omitempty"`
+}
+
+// BuildTxMsg is a message of a transaction to build.
+// Its fields are set depending on its Type:
+//   - send: ToAddress, Amount
+//   - transfer: DestChain, Receiver, Amount
+//   - delegate, undelegate: ValidatorAddress, Amount
+//   - redelegate: ValidatorAddress, ValidatorDstAddress, Amount
+//   - withdraw_rewards: ValidatorAddress
+type BuildTxMsg struct {
+	Type                string    `json:"type"`
+	ToAddress           string    `json:"to_address,omitempty"`
+	DestChain           string    `json:"dest_chain,omitempty"`
+	Receiver            string    `json:"receiver,omitempty"`
+	ValidatorAddress    string    `json:"validator_address,omitempty"`
+	ValidatorDstAddress string    `json:"validator_dst_address,omitempty"`
+	Amount              sdk.Coins `json:"amount,omitempty" swaggertype:"array,object"`
+}
+
+// BuildTxResponse is an unsigned transaction, ready to be signed either in
+// SIGN_MODE_DIRECT or in amino JSON.
+type BuildTxResponse struct {
+	ChainID       string              `json:"chain_id"`
+	AccountNumber uint64              `json:"account_number"`
+	Sequence      uint64              `json:"sequence"`
+	Fee           sdk.Coins           `json:"fee" swaggertype:"array,object"`
+	Gas           uint64              `json:"gas"`
+	Memo          string              `json:"memo"`
+	IBCTimeout    *IBCTimeoutResponse `json:"ibc_timeout,omitempty"`
+	Direct        DirectSignDoc       `json:"direct"`
+	Amino         AminoSignDoc        `json:"amino"`
+}
+
+// DirectSignDoc is a transaction to sign in SIGN_MODE_DIRECT.
+// SignBytes are the bytes to sign, the signed transaction is made of
+// BodyBytes, AuthInfoBytes and the signature.
+type DirectSignDoc struct {
+	BodyBytes     []byte `json:"body_bytes"`
+	AuthInfoBytes []byte `json:"auth_info_bytes"`
+	SignBytes     []byte `json:"sign_bytes"`
+}
+
+// AminoSignDoc is a transaction to sign in SIGN_MODE_LEGACY_AMINO_JSON.
+// SignDoc is the document to sign, the signed transaction is made of
+// BodyBytes, AuthInfoBytes and the signature.
+type AminoSignDoc struct {
+	SignDoc       json.RawMessage `json:"sign_doc" swaggertype:"object"`
+	BodyBytes     []byte          `json:"body_bytes"`
+	AuthInfoBytes []byte          `json:"auth_info_bytes"`
+}
+
+type BatchTxRequest struct {
+	Txs []BatchTxEntry `json:"txs" binding:"required"`
+	// Ordered broadcasts the txs of each chain one after the other, in the
+	// order of Txs. Once one fails, the next ones on its chain aren't sent.
+	Ordered bool `json:"ordered"`
+}
+
+type BatchTxEntry struct {
+	Chain   string `json:"chain"`
+	TxBytes []byte `json:"tx_bytes"`
+	Owner   string `json:"owner"`
+}
+
+type BatchTxResponse struct {
+	// Results holds the outcome of each tx, in the order of the request.
+	Results []BatchTxResult `json:"results"`
+}
+
+type BatchTxResult struct {
+	Chain  string                     `json:"chain"`
+	Ticket string                     `json:"ticket,omitempty"`
+	Error  *apierrors.UserFacingError `json:"error,omitempty"`
 }
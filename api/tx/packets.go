@@ -0,0 +1,216 @@
+package tx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// Packet lifecycle statuses, as reported by PacketLifecycle.Status.
+const (
+	// PacketStatusSent is reported for packets not received yet.
+	PacketStatusSent = "sent"
+	// PacketStatusReceived is reported for packets received on the
+	// destination chain, whose acknowledgement isn't relayed back yet.
+	PacketStatusReceived = "received"
+	// PacketStatusAcknowledged is reported for packets successfully
+	// acknowledged on the source chain.
+	PacketStatusAcknowledged = "acknowledged"
+	// PacketStatusRefunded is reported for packets acknowledged with an error
+	// or timed out, whose tokens were given back to the sender.
+	PacketStatusRefunded = "refunded"
+)
+
+const (
+	// sendPacketEventsPath may need updates if ibc-go/transfer events change
+	sendPacketEventsPath = `tx_response.logs.#.events.#(type=="send_packet")#`
+
+	sendPacketEvent           = "send_packet"
+	recvPacketEvent           = "recv_packet"
+	writeAcknowledgementEvent = "write_acknowledgement"
+	acknowledgePacketEvent    = "acknowledge_packet"
+	timeoutPacketEvent        = "timeout_packet"
+)
+
+// sentPacket is an IBC packet sent out by a transaction.
+type sentPacket struct {
+	Sequence         uint64
+	SourcePort       string
+	SourceChannel    string
+	DestPort         string
+	DestChannel      string
+	TimeoutHeight    string
+	TimeoutTimestamp string
+}
+
+// sentPackets returns the packets sent by the transaction data, as returned
+// by sdk-service.
+// If no IBC packets are found, the resulting slice is empty.
+func sentPackets(data []byte) []sentPacket {
+	ret := []sentPacket{}
+	for _, logEvents := range gjson.GetBytes(data, sendPacketEventsPath).Array() {
+		for _, attributes := range eventAttributes(logEvents, sendPacketEvent) {
+			sequence, err := strconv.ParseUint(attributes["packet_sequence"], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			ret = append(ret, sentPacket{
+				Sequence:         sequence,
+				SourcePort:       attributes["packet_src_port"],
+				SourceChannel:    attributes["packet_src_channel"],
+				DestPort:         attributes["packet_dst_port"],
+				DestChannel:      attributes["packet_dst_channel"],
+				TimeoutHeight:    attributes["packet_timeout_height"],
+				TimeoutTimestamp: attributes["packet_timeout_timestamp"],
+			})
+		}
+	}
+
+	return ret
+}
+
+// packetTracker follows IBC packets from their source chain to their
+// destination chain and back.
+type packetTracker struct {
+	rpc         TendermintRPC
+	sourceChain string
+	destChain   string
+}
+
+// track returns the lifecycle of packet, sent by the transaction txHash at
+// height.
+func (t packetTracker) track(ctx context.Context, txHash string, height uint64, packet sentPacket) (PacketLifecycle, error) {
+	ret := PacketLifecycle{
+		Sequence:         packet.Sequence,
+		SourcePort:       packet.SourcePort,
+		SourceChannel:    packet.SourceChannel,
+		DestPort:         packet.DestPort,
+		DestChannel:      packet.DestChannel,
+		TimeoutHeight:    packet.TimeoutHeight,
+		TimeoutTimestamp: packet.TimeoutTimestamp,
+		Status:           PacketStatusSent,
+		Sent: &PacketEvent{
+			ChainName: t.sourceChain,
+			TxHash:    txHash,
+			Height:    height,
+		},
+	}
+
+	recv, err := t.search(ctx, t.destChain, recvPacketEvent, packet.DestPort, packet.DestChannel, packet.Sequence)
+	if err != nil {
+		return PacketLifecycle{}, err
+	}
+
+	if recv != nil {
+		ret.Status = PacketStatusReceived
+		ret.Received = &PacketEvent{
+			ChainName: t.destChain,
+			TxHash:    recv.hash,
+			Height:    recv.height,
+		}
+
+		for _, attributes := range eventAttributes(recv.events, writeAcknowledgementEvent) {
+			if attributes["packet_sequence"] != strconv.FormatUint(packet.Sequence, 10) ||
+				attributes["packet_dst_channel"] != packet.DestChannel {
+				continue
+			}
+
+			ret.AckError = ackError(attributes["packet_ack"])
+			success := ret.AckError == ""
+			ret.AckSuccess = &success
+		}
+
+		ack, err := t.search(ctx, t.sourceChain, acknowledgePacketEvent, packet.SourcePort, packet.SourceChannel, packet.Sequence)
+		if err != nil {
+			return PacketLifecycle{}, err
+		}
+
+		if ack != nil {
+			ret.Acknowledged = &PacketEvent{
+				ChainName: t.sourceChain,
+				TxHash:    ack.hash,
+				Height:    ack.height,
+			}
+
+			ret.Status = PacketStatusAcknowledged
+			if ret.AckSuccess != nil && !*ret.AckSuccess {
+				ret.Status = PacketStatusRefunded
+				ret.Refunded = true
+			}
+		}
+
+		return ret, nil
+	}
+
+	timeout, err := t.search(ctx, t.sourceChain, timeoutPacketEvent, packet.SourcePort, packet.SourceChannel, packet.Sequence)
+	if err != nil {
+		return PacketLifecycle{}, err
+	}
+
+	if timeout != nil {
+		ret.Status = PacketStatusRefunded
+		ret.Refunded = true
+		ret.TimedOut = &PacketEvent{
+			ChainName: t.sourceChain,
+			TxHash:    timeout.hash,
+			Height:    timeout.height,
+		}
+	}
+
+	return ret, nil
+}
+
+// search returns the first transaction of chainName emitting eventType for
+// the packet with sequence on port/channel, or nil if there's none.
+// recv_packet events are searched by destination port and channel, the others
+// by source port and channel.
+func (t packetTracker) search(ctx context.Context, chainName, eventType, port, channel string, sequence uint64) (*searchedTx, error) {
+	side := "src"
+	if eventType == recvPacketEvent {
+		side = "dst"
+	}
+
+	query := fmt.Sprintf(
+		"%[1]s.packet_sequence=%[2]d AND %[1]s.packet_%[3]s_port='%[4]s' AND %[1]s.packet_%[3]s_channel='%[5]s'",
+		eventType,
+		sequence,
+		side,
+		port,
+		channel,
+	)
+
+	res, err := t.rpc.TxSearch(ctx, chainName, query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot search %s on %s: %w", eventType, chainName, err)
+	}
+
+	txs := searchedTxs(res)
+	if len(txs) == 0 {
+		return nil, nil
+	}
+
+	return &txs[0], nil
+}
+
+// ackError returns the error carried by an ICS-04 acknowledgement, or an
+// empty string if the acknowledgement is a success.
+func ackError(ack string) string {
+	var a struct {
+		Result []byte  `json:"result"`
+		Error  *string `json:"error"`
+	}
+
+	if err := json.Unmarshal([]byte(ack), &a); err != nil {
+		return fmt.Sprintf("unreadable acknowledgement %q", ack)
+	}
+
+	if a.Error != nil {
+		return *a.Error
+	}
+
+	return ""
+}
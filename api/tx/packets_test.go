@@ -0,0 +1,178 @@
+package tx
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stubTendermintRPC answers tx_search queries out of canned responses,
+// indexed by chain name and event type.
+type stubTendermintRPC map[string]string
+
+func (s stubTendermintRPC) TxSearch(_ context.Context, chainName, query string) ([]byte, error) {
+	eventType := query[:strings.Index(query, ".")]
+	if res, ok := s[chainName+"/"+eventType]; ok {
+		return []byte(res), nil
+	}
+
+	if chainName == "offline" {
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	return []byte(`{"result":{"txs":[],"total_count":"0"}}`), nil
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func searchResult(hash string, height int, events string) string {
+	return fmt.Sprintf(`{"result":{"txs":[{"hash":%q,"height":"%d","tx_result":{"events":[%s]}}],"total_count":"1"}}`, hash, height, events)
+}
+
+func TestSentPacketsAttributes(t *testing.T) {
+	payload := `{"tx_response":{"height":"120","logs":[{"events":[{"type":"send_packet","attributes":[
+		{"key":"packet_sequence","value":"7"},
+		{"key":"packet_src_port","value":"transfer"},
+		{"key":"packet_src_channel","value":"channel-141"},
+		{"key":"packet_dst_port","value":"transfer"},
+		{"key":"packet_dst_channel","value":"channel-0"},
+		{"key":"packet_timeout_height","value":"1-2000"},
+		{"key":"packet_timeout_timestamp","value":"0"}
+	]}]}]}}`
+
+	require.Equal(t, []sentPacket{{
+		Sequence:         7,
+		SourcePort:       "transfer",
+		SourceChannel:    "channel-141",
+		DestPort:         "transfer",
+		DestChannel:      "channel-0",
+		TimeoutHeight:    "1-2000",
+		TimeoutTimestamp: "0",
+	}}, sentPackets([]byte(payload)))
+}
+
+func TestPacketTracker(t *testing.T) {
+	packet := sentPacket{
+		Sequence:      7,
+		SourcePort:    "transfer",
+		SourceChannel: "channel-141",
+		DestPort:      "transfer",
+		DestChannel:   "channel-0",
+	}
+
+	writeAck := func(ack string) string {
+		// Tendermint v0.34 encodes attributes in base64
+		return fmt.Sprintf(`{"type":"write_acknowledgement","attributes":[
+			{"key":%q,"value":%q},
+			{"key":%q,"value":%q},
+			{"key":%q,"value":%q}
+		]}`,
+			b64("packet_sequence"), b64("7"),
+			b64("packet_dst_channel"), b64("channel-0"),
+			b64("packet_ack"), b64(ack),
+		)
+	}
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name string
+		rpc  stubTendermintRPC
+		want PacketLifecycle
+	}{
+		{
+			"sent",
+			stubTendermintRPC{},
+			PacketLifecycle{Status: PacketStatusSent},
+		},
+		{
+			"received, ack not relayed yet",
+			stubTendermintRPC{
+				"osmosis/recv_packet": searchResult("RECV", 50, writeAck(`{"result":"AQ=="}`)),
+			},
+			PacketLifecycle{
+				Status:     PacketStatusReceived,
+				Received:   &PacketEvent{ChainName: "osmosis", TxHash: "RECV", Height: 50},
+				AckSuccess: boolPtr(true),
+			},
+		},
+		{
+			"acknowledged",
+			stubTendermintRPC{
+				"osmosis/recv_packet":           searchResult("RECV", 50, writeAck(`{"result":"AQ=="}`)),
+				"cosmos-hub/acknowledge_packet": searchResult("ACK", 130, ""),
+			},
+			PacketLifecycle{
+				Status:       PacketStatusAcknowledged,
+				Received:     &PacketEvent{ChainName: "osmosis", TxHash: "RECV", Height: 50},
+				Acknowledged: &PacketEvent{ChainName: "cosmos-hub", TxHash: "ACK", Height: 130},
+				AckSuccess:   boolPtr(true),
+			},
+		},
+		{
+			"acknowledged with error",
+			stubTendermintRPC{
+				"osmosis/recv_packet":           searchResult("RECV", 50, writeAck(`{"error":"invalid receiver"}`)),
+				"cosmos-hub/acknowledge_packet": searchResult("ACK", 130, ""),
+			},
+			PacketLifecycle{
+				Status:       PacketStatusRefunded,
+				Received:     &PacketEvent{ChainName: "osmosis", TxHash: "RECV", Height: 50},
+				Acknowledged: &PacketEvent{ChainName: "cosmos-hub", TxHash: "ACK", Height: 130},
+				AckSuccess:   boolPtr(false),
+				AckError:     "invalid receiver",
+				Refunded:     true,
+			},
+		},
+		{
+			"timed out",
+			stubTendermintRPC{
+				"cosmos-hub/timeout_packet": searchResult("TIMEOUT", 140, ""),
+			},
+			PacketLifecycle{
+				Status:   PacketStatusRefunded,
+				TimedOut: &PacketEvent{ChainName: "cosmos-hub", TxHash: "TIMEOUT", Height: 140},
+				Refunded: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := packetTracker{rpc: tt.rpc, sourceChain: "cosmos-hub", destChain: "osmosis"}
+
+			res, err := tracker.track(context.Background(), "SENT", 120, packet)
+			require.NoError(t, err)
+
+			tt.want.Sequence = 7
+			tt.want.SourcePort, tt.want.SourceChannel = "transfer", "channel-141"
+			tt.want.DestPort, tt.want.DestChannel = "transfer", "channel-0"
+			tt.want.Sent = &PacketEvent{ChainName: "cosmos-hub", TxHash: "SENT", Height: 120}
+
+			require.Equal(t, tt.want, res)
+		})
+	}
+
+	t.Run("rpc failure", func(t *testing.T) {
+		tracker := packetTracker{rpc: stubTendermintRPC{}, sourceChain: "cosmos-hub", destChain: "offline"}
+
+		_, err := tracker.track(context.Background(), "SENT", 120, packet)
+		require.Error(t, err)
+	})
+}
+
+func TestHTTPTendermintRPCEndpoint(t *testing.T) {
+	rpc := NewHTTPTendermintRPC(map[string]string{"osmosis": "https://rpc.osmosis.zone/"}, "http://%s:26657")
+
+	require.Equal(t, "https://rpc.osmosis.zone", rpc.endpoint("osmosis"))
+	require.Equal(t, "http://cosmos-hub:26657", rpc.endpoint("cosmos-hub"))
+
+	rpc = NewHTTPTendermintRPC(nil, "")
+	require.Equal(t, "http://osmosis:26657", rpc.endpoint("osmosis"))
+}
@@ -0,0 +1,29 @@
+package tx
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// signerSequence returns the hex-encoded address and the sequence of the
+// first signer of a validated tx, as sequences are tracked, or false if it
+// has none.
+func signerSequence(meta TxMeta) (string, uint64, bool) {
+	if meta.Signer == "" {
+		return "", 0, false
+	}
+
+	_, address, err := bech32.DecodeAndConvert(meta.Signer)
+	if err != nil {
+		return "", 0, false
+	}
+
+	sequence, err := strconv.ParseUint(meta.SignerSequence, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return hex.EncodeToString(address), sequence, true
+}
@@ -0,0 +1,143 @@
+package tx
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultTendermintRPCURLFormat points at the in-cluster node of a chain.
+const defaultTendermintRPCURLFormat = "http://%s:26657"
+
+// TendermintRPC searches transactions through the Tendermint RPC of chains.
+type TendermintRPC interface {
+	// TxSearch returns the raw tx_search response of chainName for query.
+	TxSearch(ctx context.Context, chainName, query string) ([]byte, error)
+}
+
+// HTTPTendermintRPC queries Tendermint RPC endpoints over HTTP.
+type HTTPTendermintRPC struct {
+	endpoints map[string]string
+	urlFormat string
+	client    *http.Client
+}
+
+// NewHTTPTendermintRPC returns a TendermintRPC reaching chains at the URL set
+// in endpoints, indexed by chain name.
+// Chains missing from endpoints are reached at urlFormat, formatted with the
+// chain name, or at their in-cluster node if urlFormat is empty.
+func NewHTTPTendermintRPC(endpoints map[string]string, urlFormat string) *HTTPTendermintRPC {
+	if urlFormat == "" {
+		urlFormat = defaultTendermintRPCURLFormat
+	}
+
+	return &HTTPTendermintRPC{
+		endpoints: endpoints,
+		urlFormat: urlFormat,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+func (r *HTTPTendermintRPC) endpoint(chainName string) string {
+	if e, ok := r.endpoints[chainName]; ok {
+		return strings.TrimSuffix(e, "/")
+	}
+
+	return fmt.Sprintf(r.urlFormat, chainName)
+}
+
+func (r *HTTPTendermintRPC) TxSearch(ctx context.Context, chainName, query string) ([]byte, error) {
+	u := r.endpoint(chainName) + "/tx_search?" + url.Values{
+		"query": []string{`"` + query + `"`},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tx_search on %s returned status %s", chainName, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// searchedTx is a transaction returned by tx_search.
+type searchedTx struct {
+	hash   string
+	height uint64
+	events gjson.Result
+}
+
+// searchedTxs parses a tx_search response.
+func searchedTxs(data []byte) []searchedTx {
+	var ret []searchedTx
+	for _, tx := range gjson.GetBytes(data, "result.txs").Array() {
+		ret = append(ret, searchedTx{
+			hash:   tx.Get("hash").String(),
+			height: tx.Get("height").Uint(),
+			events: tx.Get("tx_result.events"),
+		})
+	}
+
+	return ret
+}
+
+// eventAttributes returns the attributes of every event of type eventType.
+// Tendermint up to v0.34 base64-encodes attributes, in which case they're
+// decoded.
+func eventAttributes(events gjson.Result, eventType string) []map[string]string {
+	var ret []map[string]string
+	for _, event := range events.Array() {
+		if event.Get("type").String() != eventType {
+			continue
+		}
+
+		attributes := map[string]string{}
+		for _, attr := range event.Get("attributes").Array() {
+			key, value := attr.Get("key").String(), attr.Get("value").String()
+
+			if decodedKey, err := base64.StdEncoding.DecodeString(key); err == nil && isAttributeKey(string(decodedKey)) {
+				decodedValue, _ := base64.StdEncoding.DecodeString(value)
+				key, value = string(decodedKey), string(decodedValue)
+			}
+
+			attributes[key] = value
+		}
+
+		ret = append(ret, attributes)
+	}
+
+	return ret
+}
+
+// isAttributeKey reports whether s looks like an event attribute key rather
+// than an arbitrary decoded blob.
+func isAttributeKey(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
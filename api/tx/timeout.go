@@ -0,0 +1,287 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+	"github.com/emerishq/demeris-api-server/lib/stringcache"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/emerishq/emeris-utils/store"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+)
+
+const (
+	// blockRateWindow is the number of blocks the block rate of a chain is
+	// observed over.
+	blockRateWindow = 100
+
+	blockRateCacheDuration = 10 * time.Minute
+	blockRateCachePrefix   = "api-server/block-rate"
+
+	// blockTimePath may need updates if the sdk-service block format changes
+	blockTimePath = "block.header.time"
+)
+
+// revisionFormat matches chain IDs carrying a revision number, as defined by
+// ibc-go.
+var revisionFormat = regexp.MustCompile(`^.*[^\n-]-{1}[1-9][0-9]*$`)
+
+// GetIBCTimeout returns the recommended timeout of an IBC transfer.
+// @Summary Gets the recommended timeout of an IBC transfer.
+// @Tags Tx
+// @ID ibcTimeout
+// @Description Gets the timeout height and timestamp to set on an IBC transfer from src to dest, computed from
+// @Description the latest block of dest, its observed block rate and the configured safety margin.
+// @Description Fails if dest is offline.
+// @Param src query string true "source chain name"
+// @Param dest query string true "destination chain name"
+// @Produce json
+// @Success 200 {object} IBCTimeoutResponse
+// @Failure 500,400 {object} apierrors.UserFacingError
+// @Router /tx/ibc/timeout [get]
+func GetIBCTimeout(db *database.Database, s *store.Store, sdkServiceClients sdkservice.SDKServiceClients, margin time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+
+		srcChain := c.Query("src")
+		destChain := c.Query("dest")
+		if srcChain == "" || destChain == "" {
+			e := apierrors.New(
+				"tx",
+				fmt.Sprintf("missing src or dest chain"),
+				http.StatusBadRequest,
+			)
+			_ = c.Error(e)
+
+			return
+		}
+
+		res, e := ibcTimeout(ctx, logger, db, s, sdkServiceClients, srcChain, destChain, margin)
+		if e != nil {
+			_ = c.Error(e)
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// ibcTimeout returns the recommended timeout of an IBC transfer from srcChain
+// to destChain, so that it times out margin after now.
+// The returned error is meant to be handed over to the user as is.
+func ibcTimeout(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	db *database.Database,
+	s *store.Store,
+	sdkServiceClients sdkservice.SDKServiceClients,
+	srcChain, destChain string,
+	margin time.Duration,
+) (IBCTimeoutResponse, *apierrors.Error) {
+	srcChainInfo, err := db.Chain(ctx, srcChain)
+	if err != nil {
+		e := apierrors.New(
+			"chains",
+			fmt.Sprintf("cannot retrieve chain with name %v", srcChain),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain: %w", err),
+			"name",
+			srcChain,
+		)
+		return IBCTimeoutResponse{}, e
+	}
+
+	destChainInfo, err := db.Chain(ctx, destChain)
+	if err != nil {
+		e := apierrors.New(
+			"chains",
+			fmt.Sprintf("cannot retrieve chain with name %v", destChain),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain: %w", err),
+			"name",
+			destChain,
+		)
+		return IBCTimeoutResponse{}, e
+	}
+
+	lastBlock, err := db.ChainLastBlock(ctx, destChain)
+	if err != nil || lastBlock.Height == 0 {
+		if err == nil {
+			err = fmt.Errorf("no block height known")
+		}
+
+		e := apierrors.New(
+			"tx",
+			fmt.Sprintf("cannot retrieve last block of chain %v", destChain),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve chain last block: %w", err),
+			"name",
+			destChain,
+		)
+		return IBCTimeoutResponse{}, e
+	}
+
+	if since := time.Since(lastBlock.BlockTime); since > destChainInfo.ValidBlockThresh.Duration() {
+		e := apierrors.New(
+			"tx",
+			fmt.Sprintf("destination chain %v is offline, its last block was produced %v ago", destChain, since.Truncate(time.Second)),
+			http.StatusBadRequest,
+		)
+		return IBCTimeoutResponse{}, e
+	}
+
+	client, err := sdkServiceClients.GetSDKServiceClient(destChainInfo.MajorSDKVersion())
+	if err != nil {
+		return IBCTimeoutResponse{}, apierrors.Wrap(
+			err,
+			"tx",
+			fmt.Sprintf("cannot retrieve sdk-service for chain %v", destChain),
+			http.StatusBadRequest,
+		)
+	}
+
+	rateCache := stringcache.NewStringCache(
+		logger,
+		stringcache.NewStoreBackend(s),
+		blockRateCacheDuration,
+		blockRateCachePrefix,
+		stringcache.HandlerFunc(
+			func(ctx context.Context, key string) (string, error) {
+				rate, err := observedBlockRate(ctx, client, key, lastBlock)
+				if err != nil {
+					return "", err
+				}
+
+				return strconv.FormatInt(int64(rate), 10), nil
+			},
+		),
+	)
+
+	rateString, err := rateCache.Get(ctx, destChain, false)
+	if err != nil {
+		e := apierrors.New(
+			"tx",
+			fmt.Sprintf("cannot compute block rate of chain %v", destChain),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot compute block rate: %w", err),
+			"name",
+			destChain,
+		)
+		return IBCTimeoutResponse{}, e
+	}
+
+	rate, err := strconv.ParseInt(rateString, 10, 64)
+	if err != nil || rate <= 0 {
+		e := apierrors.New(
+			"tx",
+			fmt.Sprintf("cannot compute block rate of chain %v", destChain),
+			http.StatusInternalServerError,
+		).WithLogContext(
+			fmt.Errorf("invalid cached block rate %q: %w", rateString, err),
+			"name",
+			destChain,
+		)
+		return IBCTimeoutResponse{}, e
+	}
+
+	res := recommendTimeout(destChainInfo.NodeInfo.ChainID, lastBlock, time.Duration(rate), margin, time.Now())
+	res.SourceChain = srcChain
+	res.DestChain = destChain
+	res.Channel = srcChainInfo.PrimaryChannel[destChain]
+
+	return res, nil
+}
+
+// revisionNumber returns the revision number carried by chainID, following
+// the ibc-go convention of suffixing chain IDs with "-{revision}".
+// Chain IDs not following it are at revision 0.
+func revisionNumber(chainID string) uint64 {
+	if !revisionFormat.MatchString(chainID) {
+		return 0
+	}
+
+	n, err := strconv.ParseUint(chainID[strings.LastIndex(chainID, "-")+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// observedBlockRate returns the average time between two blocks of
+// chainName over the last blockRateWindow blocks.
+func observedBlockRate(ctx context.Context, client sdkutilities.Service, chainName string, lastBlock tracelistener.BlockTimeRow) (time.Duration, error) {
+	if lastBlock.Height < 2 {
+		return 0, fmt.Errorf("not enough blocks to observe, last height is %d", lastBlock.Height)
+	}
+
+	window := uint64(blockRateWindow)
+	if lastBlock.Height <= window {
+		window = lastBlock.Height - 1
+	}
+
+	res, err := client.Block(ctx, &sdkutilities.BlockPayload{
+		ChainName: chainName,
+		Height:    int64(lastBlock.Height - window),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot retrieve block %d from sdk-service: %w", lastBlock.Height-window, err)
+	}
+
+	startTime, err := time.Parse(time.RFC3339Nano, gjson.GetBytes(res.Block, blockTimePath).String())
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse time of block %d: %w", lastBlock.Height-window, err)
+	}
+
+	elapsed := lastBlock.BlockTime.Sub(startTime)
+	if elapsed <= 0 {
+		return 0, fmt.Errorf("block %d is not older than block %d", lastBlock.Height-window, lastBlock.Height)
+	}
+
+	return elapsed / time.Duration(window), nil
+}
+
+// recommendTimeout computes the timeout of a transfer to the chain with ID
+// chainID, so that it times out margin after now.
+// The current height of the chain is extrapolated from its last known block
+// at blockRate.
+func recommendTimeout(chainID string, lastBlock tracelistener.BlockTimeRow, blockRate, margin time.Duration, now time.Time) IBCTimeoutResponse {
+	height := lastBlock.Height
+	if now.After(lastBlock.BlockTime) {
+		height += uint64(now.Sub(lastBlock.BlockTime) / blockRate)
+	}
+
+	// round up, so that the height never times out before the timestamp
+	marginBlocks := uint64((margin + blockRate - 1) / blockRate)
+
+	return IBCTimeoutResponse{
+		TimeoutHeight: IBCHeight{
+			RevisionNumber: revisionNumber(chainID),
+			RevisionHeight: height + marginBlocks,
+		},
+		TimeoutTimestamp: uint64(now.Add(margin).UnixNano()),
+		LatestHeight:     lastBlock.Height,
+		LatestBlockTime:  lastBlock.BlockTime,
+		BlockTime:        blockRate.Seconds(),
+		Margin:           margin.Seconds(),
+	}
+}
@@ -0,0 +1,51 @@
+package tx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emerishq/demeris-backend-models/tracelistener"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_revisionNumber(t *testing.T) {
+	tests := []struct {
+		chainID string
+		want    uint64
+	}{
+		{"cosmoshub-4", 4},
+		{"osmosis-1", 1},
+		{"evmos_9001-2", 2},
+		{"crescent-12", 12},
+		{"testnet", 0},
+		{"chain-0", 0},
+		{"chain--1", 0},
+		{"-1", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chainID, func(t *testing.T) {
+			require.Equal(t, tt.want, revisionNumber(tt.chainID))
+		})
+	}
+}
+
+func Test_recommendTimeout(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	lastBlock := tracelistener.BlockTimeRow{
+		TracelistenerDatabaseRow: tracelistener.TracelistenerDatabaseRow{Height: 1000},
+		BlockTime:                now.Add(-30 * time.Second),
+	}
+
+	res := recommendTimeout("cosmoshub-4", lastBlock, 6*time.Second, 10*time.Minute, now)
+
+	require.Equal(t, IBCHeight{RevisionNumber: 4, RevisionHeight: 1000 + 5 + 100}, res.TimeoutHeight)
+	require.Equal(t, uint64(now.Add(10*time.Minute).UnixNano()), res.TimeoutTimestamp)
+	require.Equal(t, uint64(1000), res.LatestHeight)
+	require.Equal(t, 6.0, res.BlockTime)
+	require.Equal(t, 600.0, res.Margin)
+
+	// margin blocks are rounded up
+	res = recommendTimeout("osmosis-1", lastBlock, 7*time.Second, time.Minute, now)
+	require.Equal(t, IBCHeight{RevisionNumber: 1, RevisionHeight: 1000 + 4 + 9}, res.TimeoutHeight)
+}
@@ -0,0 +1,321 @@
+package tx
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+
+	"github.com/emerishq/demeris-api-server/api/apiutils"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/sdkservice"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	sdkutilities "github.com/emerishq/sdk-service-meta/gen/sdk_utilities"
+)
+
+const secp256k1PubKeyType = "/cosmos.crypto.secp256k1.PubKey"
+
+// decodedTx holds the fields of a signed tx checked before broadcasting it.
+type decodedTx struct {
+	signers  []txSigner
+	fee      sdktypes.Coins
+	gasLimit uint64
+	payer    string
+}
+
+// txSigner is a signer of a tx. address is nil if it can't be derived from
+// the signer public key, e.g. for multisig or non-secp256k1 keys.
+type txSigner struct {
+	address  []byte
+	sequence uint64
+}
+
+// decodeTx decodes the signer infos and fee of the protobuf-encoded signed
+// tx txBytes.
+func decodeTx(txBytes []byte) (decodedTx, error) {
+	var raw sdktx.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		return decodedTx{}, fmt.Errorf("cannot decode tx: %w", err)
+	}
+
+	var authInfo sdktx.AuthInfo
+	if err := authInfo.Unmarshal(raw.AuthInfoBytes); err != nil {
+		return decodedTx{}, fmt.Errorf("cannot decode tx auth info: %w", err)
+	}
+
+	if len(authInfo.SignerInfos) == 0 {
+		return decodedTx{}, fmt.Errorf("tx has no signer")
+	}
+
+	if authInfo.Fee == nil {
+		return decodedTx{}, fmt.Errorf("tx has no fee")
+	}
+
+	ret := decodedTx{
+		gasLimit: authInfo.Fee.GasLimit,
+		payer:    authInfo.Fee.Payer,
+	}
+
+	for _, c := range authInfo.Fee.Amount {
+		amount, ok := sdktypes.NewIntFromString(c.Amount.String())
+		if !ok {
+			return decodedTx{}, fmt.Errorf("invalid fee amount %s", c)
+		}
+
+		ret.fee = append(ret.fee, sdktypes.Coin{Denom: c.Denom, Amount: amount})
+	}
+
+	for _, si := range authInfo.SignerInfos {
+		address, err := signerAddress(si)
+		if err != nil {
+			return decodedTx{}, err
+		}
+
+		ret.signers = append(ret.signers, txSigner{
+			address:  address,
+			sequence: si.Sequence,
+		})
+	}
+
+	return ret, nil
+}
+
+// signerAddress derives the address of the signer si from its public key.
+// It returns nil if it can't be derived, e.g. for multisig or non-secp256k1
+// keys, or if the public key isn't part of the tx.
+func signerAddress(si *sdktx.SignerInfo) ([]byte, error) {
+	if si.PublicKey == nil || si.PublicKey.TypeUrl != secp256k1PubKeyType {
+		return nil, nil
+	}
+
+	var pk secp256k1.PubKey
+	if err := pk.Unmarshal(si.PublicKey.Value); err != nil {
+		return nil, fmt.Errorf("cannot decode signer public key: %w", err)
+	}
+
+	return pk.Address(), nil
+}
+
+// checkFee checks that fee pays for gasLimit in at least one of the fee
+// tokens of chain, at its low gas price level.
+func checkFee(chain cns.Chain, fee sdktypes.Coins, gasLimit uint64) *apierrors.Error {
+	feeTokens := chain.FeeTokens()
+
+	var (
+		required []string
+		unknown  []string
+	)
+
+	for _, coin := range fee {
+		var feeToken *cns.Denom
+		for i := range feeTokens {
+			if feeTokens[i].Name == coin.Denom {
+				feeToken = &feeTokens[i]
+				break
+			}
+		}
+
+		if feeToken == nil {
+			unknown = append(unknown, coin.Denom)
+			continue
+		}
+
+		min, err := minFee(feeToken.GasPriceLevels.Low, gasLimit)
+		if err != nil {
+			return apierrors.New(
+				"tx",
+				fmt.Sprintf("cannot compute minimum fee in %s", coin.Denom),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot compute minimum fee: %w", err),
+				"denom",
+				coin.Denom,
+			)
+		}
+
+		if coin.Amount.GTE(min) {
+			return nil
+		}
+
+		required = append(required, min.String()+coin.Denom)
+	}
+
+	switch {
+	case len(required) > 0:
+		return apierrors.New(
+			"tx",
+			fmt.Sprintf("insufficient fee %s for %d gas, at least %s required", fee, gasLimit, strings.Join(required, " or ")),
+			http.StatusBadRequest,
+		)
+	case len(unknown) > 0:
+		return apierrors.New(
+			"tx",
+			fmt.Sprintf("%s cannot be used to pay fees on chain %s", strings.Join(unknown, ", "), chain.ChainName),
+			http.StatusBadRequest,
+		)
+	}
+
+	// no fee, which is only fine if gas is free in some fee token
+	if len(feeTokens) == 0 {
+		return nil
+	}
+
+	for _, ft := range feeTokens {
+		if min, err := minFee(ft.GasPriceLevels.Low, gasLimit); err == nil && min.IsZero() {
+			return nil
+		}
+	}
+
+	return apierrors.New(
+		"tx",
+		fmt.Sprintf("missing fee, chain %s requires fees", chain.ChainName),
+		http.StatusBadRequest,
+	)
+}
+
+// minFee returns the fee amount paying for gasLimit at gasPrice, rounded up.
+func minFee(gasPrice float64, gasLimit uint64) (sdktypes.Int, error) {
+	price, err := sdktypes.NewDecFromStr(strconv.FormatFloat(gasPrice, 'f', -1, 64))
+	if err != nil {
+		return sdktypes.Int{}, err
+	}
+
+	return price.MulInt(sdktypes.NewIntFromUint64(gasLimit)).Ceil().TruncateInt(), nil
+}
+
+// checkSequence checks the sequence of signer against its account sequence
+// on chain.
+// Sequences ahead of the account sequence are accepted, since they're valid
+// as long as the txs with the preceding sequences are in the mempool.
+func checkSequence(chain cns.Chain, signer txSigner, account uint64) *apierrors.Error {
+	if signer.sequence >= account {
+		return nil
+	}
+
+	address, _ := bech32.ConvertAndEncode(chain.NodeInfo.Bech32Config.Bech32PrefixAccAddr(), signer.address)
+
+	return apierrors.New(
+		"tx",
+		fmt.Sprintf("sequence %d of signer %s was already used, expected %d", signer.sequence, address, account),
+		http.StatusBadRequest,
+	)
+}
+
+// validateTx checks txBytes before broadcasting it on chain, and returns its
+// metadata.
+// The returned error is meant to be handed over to the user as is.
+func validateTx(
+	ctx context.Context,
+	db *database.Database,
+	client sdkutilities.Service,
+	sdkServiceClients sdkservice.SDKServiceClients,
+	chain cns.Chain,
+	txBytes []byte,
+) (TxMeta, *apierrors.Error) {
+	meta := TxMeta{
+		Chain: chain,
+	}
+
+	lastBlock, err := db.ChainLastBlock(ctx, chain.ChainName)
+	if err != nil || time.Since(lastBlock.BlockTime) > chain.ValidBlockThresh.Duration() {
+		if err == nil {
+			err = fmt.Errorf("last block produced at %s", lastBlock.BlockTime)
+		}
+
+		return meta, apierrors.New(
+			"tx",
+			fmt.Sprintf("chain %s is offline", chain.ChainName),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("chain offline: %w", err),
+			"name",
+			chain.ChainName,
+		)
+	}
+
+	txMetadata, err := client.TxMetadata(ctx, &sdkutilities.TxMetadataPayload{
+		TxBytes: txBytes,
+	})
+	if err != nil {
+		return meta, apierrors.New(
+			"tx",
+			"cannot decode tx",
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot retrieve tx metadata from sdk-service: %w", err),
+			"name",
+			chain.ChainName,
+		)
+	}
+
+	var msgTypes []string
+	for _, m := range txMetadata.MessagesMetadata {
+		msgTypes = append(msgTypes, m.MsgType)
+	}
+
+	meta.TxType = strings.Join(msgTypes, ",")
+
+	tx, err := decodeTx(txBytes)
+	if err != nil {
+		return meta, apierrors.New(
+			"tx",
+			fmt.Sprintf("malformed tx, %v", err),
+			http.StatusBadRequest,
+		).WithLogContext(
+			fmt.Errorf("cannot decode tx: %w", err),
+			"name",
+			chain.ChainName,
+		)
+	}
+
+	if e := checkFee(chain, tx.fee, tx.gasLimit); e != nil {
+		return meta, e
+	}
+
+	prefix := chain.NodeInfo.Bech32Config.Bech32PrefixAccAddr()
+	for i, signer := range tx.signers {
+		if signer.address == nil {
+			continue
+		}
+
+		account, err := apiutils.FetchAccountNumbers(ctx, chain, hex.EncodeToString(signer.address), sdkServiceClients)
+		if err != nil {
+			return meta, apierrors.New(
+				"tx",
+				fmt.Sprintf("cannot retrieve account of signer %d", i),
+				http.StatusInternalServerError,
+			).WithLogContext(
+				fmt.Errorf("cannot fetch account numbers: %w", err),
+				"name",
+				chain.ChainName,
+			)
+		}
+
+		if e := checkSequence(chain, signer, account.SequenceNumber); e != nil {
+			return meta, e
+		}
+
+		if i == 0 {
+			meta.Signer, _ = bech32.ConvertAndEncode(prefix, signer.address)
+			meta.SignerSequence = strconv.FormatUint(signer.sequence, 10)
+		}
+	}
+
+	meta.FeePayer = tx.payer
+	if meta.FeePayer == "" {
+		meta.FeePayer = meta.Signer
+	}
+
+	meta.Valid = true
+
+	return meta, nil
+}
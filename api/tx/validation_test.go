@@ -0,0 +1,127 @@
+package tx
+
+import (
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/exported/sdktypes"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTx(t *testing.T, authInfo sdktx.AuthInfo) []byte {
+	t.Helper()
+
+	authInfoBytes, err := authInfo.Marshal()
+	require.NoError(t, err)
+
+	raw := sdktx.TxRaw{
+		AuthInfoBytes: authInfoBytes,
+		Signatures:    [][]byte{[]byte("signature")},
+	}
+
+	bz, err := raw.Marshal()
+	require.NoError(t, err)
+
+	return bz
+}
+
+func TestDecodeTx(t *testing.T) {
+	pk := secp256k1.GenPrivKey().PubKey().(*secp256k1.PubKey)
+	pkBytes, err := pk.Marshal()
+	require.NoError(t, err)
+
+	txBytes := encodeTx(t, sdktx.AuthInfo{
+		SignerInfos: []*sdktx.SignerInfo{
+			{PublicKey: &codectypes.Any{TypeUrl: secp256k1PubKeyType, Value: pkBytes}, Sequence: 12},
+			{PublicKey: &codectypes.Any{TypeUrl: "/cosmos.crypto.multisig.LegacyAminoPubKey"}, Sequence: 3},
+		},
+		Fee: &sdktx.Fee{
+			Amount:   sdk.NewCoins(sdk.NewInt64Coin("uatom", 5000)),
+			GasLimit: 200000,
+			Payer:    "cosmos1payer",
+		},
+	})
+
+	tx, err := decodeTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, uint64(200000), tx.gasLimit)
+	require.Equal(t, "cosmos1payer", tx.payer)
+	require.Equal(t, "5000uatom", tx.fee.String())
+	require.Equal(t, []txSigner{
+		{address: pk.Address(), sequence: 12},
+		{sequence: 3},
+	}, tx.signers)
+
+	_, err = decodeTx([]byte("not a tx"))
+	require.Error(t, err)
+
+	_, err = decodeTx(encodeTx(t, sdktx.AuthInfo{Fee: &sdktx.Fee{}}))
+	require.Error(t, err)
+}
+
+func TestCheckFee(t *testing.T) {
+	chain := cns.Chain{
+		ChainName: "cosmos-hub",
+		Denoms: cns.DenomList{
+			{Name: "uatom", FeeToken: true, GasPriceLevels: cns.GasPrice{Low: 0.01, Average: 0.025, High: 0.04}},
+			{Name: "ustake", FeeToken: true, GasPriceLevels: cns.GasPrice{Low: 0.5}},
+			{Name: "uother"},
+		},
+	}
+
+	coins := func(s string) sdktypes.Coins {
+		if s == "" {
+			return nil
+		}
+
+		c, err := sdktypes.ParseCoinsNormalized(s)
+		require.NoError(t, err)
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		fee     string
+		gas     uint64
+		wantErr string
+	}{
+		{"exact low price", "2000uatom", 200000, ""},
+		{"rounded up", "2uatom", 101, ""},
+		{"insufficient", "1999uatom", 200000, "insufficient fee 1999uatom for 200000 gas, at least 2000uatom required"},
+		{"one sufficient coin", "1uatom,100000ustake", 200000, ""},
+		{"not a fee token", "5000uother", 200000, "uother cannot be used to pay fees on chain cosmos-hub"},
+		{"missing fee", "", 200000, "missing fee, chain cosmos-hub requires fees"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := checkFee(chain, coins(tt.fee), tt.gas)
+			if tt.wantErr == "" {
+				require.Nil(t, e)
+				return
+			}
+
+			require.NotNil(t, e)
+			require.Equal(t, tt.wantErr, e.Cause)
+		})
+	}
+
+	free := cns.Chain{Denoms: cns.DenomList{{Name: "ufree", FeeToken: true}}}
+	require.Nil(t, checkFee(free, nil, 200000))
+}
+
+func TestCheckSequence(t *testing.T) {
+	chain := cns.Chain{NodeInfo: cns.NodeInfo{Bech32Config: cns.Bech32Config{MainPrefix: "cosmos"}}}
+	signer := txSigner{address: make([]byte, 20), sequence: 5}
+
+	require.Nil(t, checkSequence(chain, signer, 5))
+	require.Nil(t, checkSequence(chain, signer, 4))
+
+	e := checkSequence(chain, signer, 6)
+	require.NotNil(t, e)
+	require.Contains(t, e.Cause, "sequence 5 of signer cosmos1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqnrql8a was already used, expected 6")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/emerishq/demeris-api-server/api/config"
+	"github.com/emerishq/demeris-api-server/api/database"
+	"github.com/emerishq/demeris-api-server/api/ibc"
+	"go.uber.org/zap"
+)
+
+// auditPrimaryChannelsCmd is the subcommand auditing CNS primary channels
+// instead of running the server.
+const auditPrimaryChannelsCmd = "audit-primary-channels"
+
+// auditPrimaryChannels prints the primary channel issues found and returns
+// the process exit code, which is non-zero if there's any.
+func auditPrimaryChannels(cfg *config.Config, l *zap.SugaredLogger) int {
+	dbi, err := database.Init(cfg)
+	if err != nil {
+		l.Errorw("cannot initialize database", "error", err)
+		return 2
+	}
+
+	audit, err := ibc.AuditPrimaryChannels(context.Background(), dbi)
+	if err != nil {
+		l.Errorw("cannot audit primary channels", "error", err)
+		return 2
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tCHAIN\tCOUNTERPARTY\tCHANNEL\tDETAILS")
+	for _, i := range audit.Issues {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", i.Kind, i.ChainName, i.CounterpartyName, i.Channel, i.Details)
+	}
+	_ = w.Flush()
+
+	fmt.Printf("\n%d primary channels checked, %d issues found\n", audit.Checked, len(audit.Issues))
+
+	if len(audit.Issues) > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"runtime"
 	"runtime/debug"
 	"time"
@@ -55,11 +56,19 @@ func main() {
 
 	l.Infow("api-server", "version", Version)
 
+	if len(os.Args) > 1 && os.Args[1] == auditPrimaryChannelsCmd {
+		os.Exit(auditPrimaryChannels(cfg, l))
+	}
+
 	dbi, err := database.Init(cfg)
 	if err != nil {
 		l.Panicw("cannot initialize database", "error", err)
 	}
 
+	if err := dbi.RunMigrations(); err != nil {
+		l.Panicw("cannot run database migrations", "error", err)
+	}
+
 	s, err := store.NewClient(cfg.RedisAddr)
 	if err != nil {
 		l.Panicw("unable to start redis client", "error", err)
@@ -119,7 +128,7 @@ func main() {
 		informer,
 		sdkServiceClients,
 		app,
-		cfg.Debug,
+		cfg,
 	)
 
 	if err := r.Serve(cfg.ListenAddr); err != nil {
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+	"github.com/emerishq/demeris-api-server/lib/ginutils"
+)
+
+// Rate limit response headers.
+const (
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderReset      = "X-RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// ownerPath is the path of the owner of a request in its JSON body.
+const ownerPath = "owner"
+
+// Middleware returns a middleware limiting the requests to route by limit.
+// Each IP has its own bucket per chain, and so does each owner of requests,
+// which is set in their JSON body. Requests are rejected with a 429 status if
+// any of their buckets is empty. If the buckets can't be taken from, requests
+// go through.
+func (l *Limiter) Middleware(route string, limit Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		ip := c.ClientIP()
+		if l.Exempt(ip) {
+			c.Next()
+			return
+		}
+
+		owner := requestOwner(c)
+
+		res, err := l.TakeClient(c.Request.Context(), route, c.Param("chain"), ip, owner, limit)
+		if err != nil {
+			logger := ginutils.GetValue[*zap.SugaredLogger](c, logging.LoggerKey)
+			logger.Errorw("cannot rate limit request", "route", route, "error", err)
+
+			c.Next()
+			return
+		}
+
+		c.Header(HeaderLimit, strconv.Itoa(limit.Burst))
+		c.Header(HeaderRemaining, strconv.Itoa(res.Remaining))
+		c.Header(HeaderReset, seconds(res.Reset))
+
+		if !res.Allowed {
+			c.Header(HeaderRetryAfter, seconds(res.RetryAfter))
+
+			e := TooManyRequests(res).WithLogContext(
+				fmt.Errorf("rate limit of %s exceeded", route),
+				"ip",
+				ip,
+				"owner",
+				owner,
+			)
+			_ = c.Error(e)
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TooManyRequests returns the error of a request rejected with res.
+func TooManyRequests(res Result) *apierrors.Error {
+	return apierrors.New(
+		"ratelimit",
+		fmt.Sprintf("too many requests, retry in %s seconds", seconds(res.RetryAfter)),
+		http.StatusTooManyRequests,
+	)
+}
+
+// requestOwner returns the owner set in the JSON body of the request, which
+// is left for handlers to read.
+func requestOwner(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	_ = c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err != nil {
+		return ""
+	}
+
+	return gjson.GetBytes(body, ownerPath).String()
+}
+
+// seconds formats d as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
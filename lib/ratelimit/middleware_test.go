@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emerishq/emeris-utils/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/emerishq/demeris-api-server/lib/apierrors"
+)
+
+func newTestEngine(l *Limiter, limit Limit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	_ = engine.SetTrustedProxies([]string{"198.51.100.1"})
+	engine.Use(func(c *gin.Context) {
+		c.Set(logging.LoggerKey, zap.NewNop().Sugar())
+		c.Next()
+
+		e := &apierrors.Error{}
+		if l := c.Errors.Last(); l != nil && errors.As(l, &e) {
+			c.JSON(e.StatusCode, e.Error())
+		}
+	})
+
+	engine.POST("/tx/:chain", l.Middleware("tx", limit), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	return engine
+}
+
+func post(engine *gin.Engine, chain, body, ip string) *httptest.ResponseRecorder {
+	return postForwarded(engine, chain, body, ip, "")
+}
+
+func postForwarded(engine *gin.Engine, chain, body, ip, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tx/"+chain, strings.NewReader(body))
+	req.RemoteAddr = net.JoinHostPort(ip, "4242")
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	l, _ := newTestLimiter(t, Allowlist{{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)}})
+	engine := newTestEngine(l, Limit{Rate: 1, Burst: 2})
+
+	body := `{"owner":"alice","tx_bytes":"AA=="}`
+
+	w := post(engine, "cosmos-hub", body, "192.0.2.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, body, w.Body.String(), "the body is left for the handler")
+	require.Equal(t, "2", w.Header().Get(HeaderLimit))
+	require.Equal(t, "1", w.Header().Get(HeaderRemaining))
+	require.Equal(t, "1", w.Header().Get(HeaderReset))
+	require.Empty(t, w.Header().Get(HeaderRetryAfter))
+
+	require.Equal(t, http.StatusOK, post(engine, "cosmos-hub", body, "192.0.2.1").Code)
+
+	w = post(engine, "cosmos-hub", body, "192.0.2.1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "0", w.Header().Get(HeaderRemaining))
+	require.Equal(t, "1", w.Header().Get(HeaderRetryAfter))
+
+	// changing owner doesn't refill the IP bucket, nor changing IP the owner one
+	require.Equal(t, http.StatusTooManyRequests, post(engine, "cosmos-hub", `{"owner":"bob"}`, "192.0.2.1").Code)
+	require.Equal(t, http.StatusTooManyRequests, post(engine, "cosmos-hub", body, "192.0.2.2").Code)
+
+	// buckets are per chain
+	require.Equal(t, http.StatusOK, post(engine, "osmosis", body, "192.0.2.1").Code)
+
+	// requests without owner only take from the IP bucket
+	require.Equal(t, http.StatusOK, post(engine, "cosmos-hub", `{}`, "192.0.2.3").Code)
+	require.Equal(t, http.StatusOK, post(engine, "cosmos-hub", `{"owner":"carol"}`, "192.0.2.3").Code)
+	require.Equal(t, http.StatusTooManyRequests, post(engine, "cosmos-hub", `{"owner":"dave"}`, "192.0.2.3").Code)
+
+	// allowlisted clients aren't limited
+	for i := 0; i < 5; i++ {
+		w = post(engine, "cosmos-hub", body, "10.1.2.3")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Get(HeaderLimit))
+	}
+}
+
+func TestLimiter_Middleware_forwarded(t *testing.T) {
+	l, _ := newTestLimiter(t, Allowlist{{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)}})
+	engine := newTestEngine(l, Limit{Rate: 1, Burst: 1})
+
+	// untrusted clients can't pick their IP
+	require.Equal(t, http.StatusOK, postForwarded(engine, "cosmos-hub", `{}`, "192.0.2.1", "192.0.2.2").Code)
+	require.Equal(t, http.StatusTooManyRequests, postForwarded(engine, "cosmos-hub", `{}`, "192.0.2.1", "192.0.2.3").Code)
+	require.Equal(t, http.StatusTooManyRequests, postForwarded(engine, "cosmos-hub", `{}`, "192.0.2.1", "10.1.2.3").Code)
+
+	// trusted proxies forward the IP of clients
+	require.Equal(t, http.StatusOK, postForwarded(engine, "cosmos-hub", `{}`, "198.51.100.1", "192.0.2.2").Code)
+	require.Equal(t, http.StatusTooManyRequests, postForwarded(engine, "cosmos-hub", `{}`, "198.51.100.1", "192.0.2.2").Code)
+	require.Equal(t, http.StatusOK, postForwarded(engine, "cosmos-hub", `{}`, "198.51.100.1", "10.1.2.3").Code)
+}
+
+func TestLimiter_Middleware_disabled(t *testing.T) {
+	l, _ := newTestLimiter(t, nil)
+	engine := newTestEngine(l, Limit{})
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, post(engine, "cosmos-hub", `{}`, "192.0.2.1").Code)
+	}
+}
@@ -0,0 +1,237 @@
+// Package ratelimit limits the requests of clients with token buckets kept in
+// redis, so that every replica of the api-server shares them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// keyPrefix prefixes the redis keys of buckets.
+const keyPrefix = "api-server/ratelimit"
+
+// takeToken atomically refills buckets for the time elapsed since they were
+// last taken from, then takes a token from each of them if there's one left
+// in all of them.
+// It returns whether tokens were taken, followed by the tokens left in each
+// bucket as strings so that their fractional part is kept.
+//
+// KEYS: buckets
+// ARGV[1]: tokens refilled per millisecond
+// ARGV[2]: bucket size
+// ARGV[3]: now, in unix milliseconds
+var takeToken = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local taken = 1
+local left = {}
+for i, key in ipairs(KEYS) do
+	local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+	local tokens = tonumber(bucket[1]) or burst
+	local ts = tonumber(bucket[2]) or now
+
+	left[i] = math.min(burst, tokens + math.max(0, now - ts) * rate)
+	if left[i] < 1 then
+		taken = 0
+	end
+end
+
+local ret = {taken}
+for i, key in ipairs(KEYS) do
+	left[i] = left[i] - taken
+
+	redis.call('HMSET', key, 'tokens', tostring(left[i]), 'ts', ARGV[3])
+	redis.call('PEXPIRE', key, math.ceil((burst - left[i]) / rate) + 1)
+
+	ret[i + 1] = tostring(left[i])
+end
+
+return ret
+`)
+
+// Limit is a token bucket of Burst tokens, refilled at Rate tokens per
+// second. Each request takes a token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled tells whether l limits anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the state of a bucket once a token was taken from it.
+type Result struct {
+	// Allowed is false if the bucket was empty.
+	Allowed bool
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// RetryAfter is how long until a token is available again.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Limiter takes tokens from buckets kept in a store.
+type Limiter struct {
+	s         *store.Store
+	allowlist Allowlist
+	now       func() time.Time
+}
+
+// New returns a Limiter keeping its buckets in s, which never limits the
+// clients in allowlist.
+func New(s *store.Store, allowlist Allowlist) *Limiter {
+	return &Limiter{
+		s:         s,
+		allowlist: allowlist,
+		now:       time.Now,
+	}
+}
+
+// Take takes a token from the bucket key, limited by limit.
+func (l *Limiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.TakeAll(ctx, []string{key}, limit)
+}
+
+// TakeAll takes a token from each of the buckets keys, limited by limit, if
+// none of them is empty. The returned Result is the one of the most limiting
+// bucket.
+func (l *Limiter) TakeAll(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	rate := limit.Rate / float64(time.Second/time.Millisecond)
+
+	bucketKeys := make([]string, 0, len(keys))
+	for _, k := range keys {
+		bucketKeys = append(bucketKeys, fmt.Sprintf("%s/%s", keyPrefix, k))
+	}
+
+	res, err := takeToken.Run(
+		ctx,
+		l.s.Client,
+		bucketKeys,
+		strconv.FormatFloat(rate, 'f', -1, 64),
+		limit.Burst,
+		l.now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("cannot take token, %w", err)
+	}
+
+	if len(res) != len(keys)+1 {
+		return Result{}, fmt.Errorf("cannot take token, unexpected result %v", res)
+	}
+
+	taken, _ := res[0].(int64)
+	ret := Result{
+		Allowed:   taken == 1,
+		Remaining: limit.Burst,
+	}
+
+	for _, r := range res[1:] {
+		left, _ := r.(string)
+
+		tokens, err := strconv.ParseFloat(left, 64)
+		if err != nil {
+			return Result{}, fmt.Errorf("cannot parse tokens left, %w", err)
+		}
+
+		if remaining := int(math.Floor(tokens)); remaining < ret.Remaining {
+			ret.Remaining = remaining
+		}
+
+		if reset := tokensDelay(float64(limit.Burst)-tokens, rate); reset > ret.Reset {
+			ret.Reset = reset
+		}
+
+		if retryAfter := tokensDelay(1-tokens, rate); !ret.Allowed && retryAfter > ret.RetryAfter {
+			ret.RetryAfter = retryAfter
+		}
+	}
+
+	return ret, nil
+}
+
+// TakeClient takes a token from the buckets of a client for route on chain,
+// limited by limit: one per IP and, if owner is set, one per owner.
+func (l *Limiter) TakeClient(ctx context.Context, route, chain, ip, owner string, limit Limit) (Result, error) {
+	keys := []string{fmt.Sprintf("%s/%s/ip/%s", route, chain, ip)}
+	if owner != "" {
+		keys = append(keys, fmt.Sprintf("%s/%s/owner/%s", route, chain, owner))
+	}
+
+	return l.TakeAll(ctx, keys, limit)
+}
+
+// Exempt tells whether the client ip is never rate limited.
+func (l *Limiter) Exempt(ip string) bool {
+	return l.allowlist.Contains(net.ParseIP(ip))
+}
+
+// tokensDelay returns how long it takes to refill tokens at rate tokens per
+// millisecond.
+func tokensDelay(tokens, rate float64) time.Duration {
+	return time.Duration(math.Ceil(tokens/rate)) * time.Millisecond
+}
+
+// Allowlist holds the networks of clients which aren't rate limited, such as
+// internal services.
+type Allowlist []*net.IPNet
+
+// ParseAllowlist returns the Allowlist of entries, each either an IP or a
+// CIDR.
+func ParseAllowlist(entries []string) (Allowlist, error) {
+	ret := Allowlist{}
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %s", e)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			ret = append(ret, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %s, %w", e, err)
+		}
+
+		ret = append(ret, network)
+	}
+
+	return ret, nil
+}
+
+// Contains tells whether ip belongs to a network of a.
+func (a Allowlist) Contains(ip net.IP) bool {
+	for _, n := range a {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
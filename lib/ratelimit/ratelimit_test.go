@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter returns a Limiter backed by miniredis, whose clock is
+// returned to be moved by tests.
+func newTestLimiter(t *testing.T, allowlist Allowlist) (*Limiter, *time.Time) {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+	s, err := store.NewClient(m.Addr())
+	require.NoError(t, err)
+
+	now := time.Unix(1650000000, 0)
+	l := New(s, allowlist)
+	l.now = func() time.Time { return now }
+
+	return l, &now
+}
+
+func TestLimiter_Take(t *testing.T) {
+	ctx := context.Background()
+	l, now := newTestLimiter(t, nil)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := l.Take(ctx, "key", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Equal(t, i, res.Remaining)
+		require.Zero(t, res.RetryAfter)
+	}
+
+	res, err := l.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	require.Equal(t, 1500*time.Millisecond, res.Reset)
+
+	// other buckets are left alone
+	res, err = l.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	*now = now.Add(250 * time.Millisecond)
+	res, err = l.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 250*time.Millisecond, res.RetryAfter)
+
+	*now = now.Add(250 * time.Millisecond)
+	res, err = l.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+
+	// buckets don't fill over their size
+	*now = now.Add(time.Hour)
+	res, err = l.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 2, res.Remaining)
+	require.Equal(t, 500*time.Millisecond, res.Reset)
+}
+
+func TestLimiter_TakeAll(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter(t, nil)
+	limit := Limit{Rate: 1, Burst: 2}
+
+	res, err := l.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	res, err = l.TakeAll(ctx, []string{"a", "b"}, limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining, "the most limiting bucket is reported")
+	require.Equal(t, 2*time.Second, res.Reset)
+
+	// no token is taken unless all buckets have one
+	res, err = l.TakeAll(ctx, []string{"a", "b"}, limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, time.Second, res.RetryAfter)
+
+	res, err = l.Take(ctx, "b", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+}
+
+func TestLimiter_Take_slowRate(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter(t, nil)
+	limit := Limit{Rate: 0.001, Burst: 1}
+
+	res, err := l.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	res, err = l.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 1000*time.Second, res.RetryAfter)
+}
+
+func TestParseAllowlist(t *testing.T) {
+	a, err := ParseAllowlist([]string{"10.0.0.0/8", " 192.168.1.1 ", "", "::1"})
+	require.NoError(t, err)
+	require.Len(t, a, 3)
+
+	require.True(t, a.Contains(net.ParseIP("10.1.2.3")))
+	require.True(t, a.Contains(net.ParseIP("192.168.1.1")))
+	require.True(t, a.Contains(net.ParseIP("::1")))
+	require.False(t, a.Contains(net.ParseIP("192.168.1.2")))
+	require.False(t, a.Contains(nil))
+
+	_, err = ParseAllowlist([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = ParseAllowlist([]string{"localhost"})
+	require.Error(t, err)
+}